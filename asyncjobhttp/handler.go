@@ -0,0 +1,177 @@
+// Package asyncjobhttp serves an admin API to inspect and cancel job instances of an asyncjob.JobManager.
+//
+//	GET  /jobs?definition=<name>&state=<state>[,<state>]  list of job snapshots
+//	GET  /jobs/<jobId>                                     job snapshot
+//	GET  /jobs/<jobId>/graph?format=dot|mermaid            graph of the job instance
+//	POST /jobs/<jobId>/cancel                              cancel the job instance
+//	GET  /definitions                                      names of registered job definitions
+//	GET  /definitions/<name>/graph?format=dot|mermaid      graph of the job definition
+//
+// mount it with http.StripPrefix if it is not served at root.
+package asyncjobhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-asyncjob"
+)
+
+type handler struct {
+	manager *asyncjob.JobManager
+}
+
+// NewHandler returns a http.Handler serving job instances and definitions of manager.
+func NewHandler(manager *asyncjob.JobManager) http.Handler {
+	return &handler{manager: manager}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	for i, segment := range segments {
+		// job id and definition name can have escaped characters.
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segments[i] = unescaped
+		}
+	}
+
+	switch {
+	case len(segments) == 1 && segments[0] == "jobs":
+		h.get(w, r, h.listJobs)
+	case len(segments) == 2 && segments[0] == "jobs":
+		h.get(w, r, func(w http.ResponseWriter, r *http.Request) { h.getJob(w, segments[1]) })
+	case len(segments) == 3 && segments[0] == "jobs" && segments[2] == "graph":
+		h.get(w, r, func(w http.ResponseWriter, r *http.Request) { h.getJobGraph(w, r, segments[1]) })
+	case len(segments) == 3 && segments[0] == "jobs" && segments[2] == "cancel":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed, use POST", r.Method))
+			return
+		}
+		h.cancelJob(w, segments[1])
+	case len(segments) == 1 && segments[0] == "definitions":
+		h.get(w, r, h.listDefinitions)
+	case len(segments) == 3 && segments[0] == "definitions" && segments[2] == "graph":
+		h.get(w, r, func(w http.ResponseWriter, r *http.Request) { h.getDefinitionGraph(w, r, segments[1]) })
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("path %q not found", r.URL.Path))
+	}
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request, handle http.HandlerFunc) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed, use GET", r.Method))
+		return
+	}
+
+	handle(w, r)
+}
+
+func (h *handler) listJobs(w http.ResponseWriter, r *http.Request) {
+	filter := asyncjob.JobFilter{DefinitionName: r.URL.Query().Get("definition")}
+	if states := r.URL.Query().Get("state"); states != "" {
+		for _, state := range strings.Split(states, ",") {
+			filter.States = append(filter.States, asyncjob.JobState(state))
+		}
+	}
+
+	snapshots := []*asyncjob.JobSnapshot{}
+	for _, instance := range h.manager.List(filter) {
+		snapshots = append(snapshots, instance.Snapshot())
+	}
+
+	writeJSON(w, http.StatusOK, snapshots)
+}
+
+func (h *handler) getJob(w http.ResponseWriter, jobId string) {
+	instance, ok := h.manager.Get(jobId)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %q not found", jobId))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, instance.Snapshot())
+}
+
+func (h *handler) getJobGraph(w http.ResponseWriter, r *http.Request, jobId string) {
+	instance, ok := h.manager.Get(jobId)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %q not found", jobId))
+		return
+	}
+
+	format := visualizeFormat(r)
+	writeGraph(w, format, func() (string, error) { return instance.Visualize(asyncjob.WithVisualizeFormat(format)) })
+}
+
+func (h *handler) cancelJob(w http.ResponseWriter, jobId string) {
+	instance, ok := h.manager.Get(jobId)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %q not found", jobId))
+		return
+	}
+
+	instance.Cancel()
+	writeJSON(w, http.StatusAccepted, instance.Snapshot())
+}
+
+func (h *handler) listDefinitions(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for _, definition := range h.manager.ListDefinitions() {
+		names = append(names, definition.GetName())
+	}
+
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (h *handler) getDefinitionGraph(w http.ResponseWriter, r *http.Request, name string) {
+	definition, ok := h.manager.GetDefinition(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job definition %q not found", name))
+		return
+	}
+
+	format := visualizeFormat(r)
+	writeGraph(w, format, func() (string, error) { return definition.Visualize(asyncjob.WithVisualizeFormat(format)) })
+}
+
+func visualizeFormat(r *http.Request) asyncjob.VisualizeFormat {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return asyncjob.VisualizeFormat(format)
+	}
+
+	return asyncjob.VisualizeFormatDot
+}
+
+func writeGraph(w http.ResponseWriter, format asyncjob.VisualizeFormat, visualize func() (string, error)) {
+	graph, err := visualize()
+	if errors.Is(err, asyncjob.ErrUnsupportedVisualizeFormat) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	contentType := "text/vnd.graphviz; charset=utf-8"
+	if format == asyncjob.VisualizeFormatMermaid {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(graph))
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,164 @@
+package asyncjobhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asyncjob/asyncjobhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[string]("adminJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Block", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}, asyncjob.WithOwner("admin-team"))
+	assert.NoError(t, err)
+
+	manager := asyncjob.NewJobManager()
+	assert.NoError(t, manager.Register(jd))
+	job, err := manager.Start(context.Background(), "adminJob", "input", asyncjob.WithJobId("job/1"))
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.StripPrefix("/admin", asyncjobhttp.NewHandler(manager)))
+	defer server.Close()
+
+	status, body := request(t, http.MethodGet, server.URL+"/admin/jobs?state=running")
+	assert.Equal(t, http.StatusOK, status)
+	var snapshots []*asyncjob.JobSnapshot
+	assert.NoError(t, json.Unmarshal([]byte(body), &snapshots))
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, "job/1", snapshots[0].JobId)
+	assert.Equal(t, "admin-team", snapshots[0].Steps[0].Owner)
+
+	status, body = request(t, http.MethodGet, server.URL+"/admin/jobs?state=failed")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "[]\n", body)
+
+	status, body = request(t, http.MethodGet, server.URL+"/admin/jobs/job%2F1/graph")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, "digraph")
+
+	status, body = request(t, http.MethodGet, server.URL+"/admin/jobs/job%2F1/graph?format=png")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, body, "UnsupportedVisualizeFormat")
+
+	status, _ = request(t, http.MethodGet, server.URL+"/admin/jobs/job%2F1/cancel")
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+	status, _ = request(t, http.MethodPost, server.URL+"/admin/jobs/job%2F1/cancel")
+	assert.Equal(t, http.StatusAccepted, status)
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "job is not cancelled")
+	}
+
+	status, body = request(t, http.MethodGet, server.URL+"/admin/jobs/job%2F1")
+	assert.Equal(t, http.StatusOK, status)
+	snapshot := &asyncjob.JobSnapshot{}
+	assert.NoError(t, json.Unmarshal([]byte(body), snapshot))
+	assert.Equal(t, asyncjob.JobStateCancelled, snapshot.State)
+
+	status, _ = request(t, http.MethodGet, server.URL+"/admin/jobs/notExists")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, body = request(t, http.MethodGet, server.URL+"/admin/definitions")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "[\"adminJob\"]\n", body)
+
+	status, body = request(t, http.MethodGet, server.URL+"/admin/definitions/adminJob/graph?format=mermaid")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, strings.Contains(body, "flowchart"))
+
+	status, _ = request(t, http.MethodGet, server.URL+"/admin/unknown")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+type retryTransient struct{}
+
+func (retryTransient) ShouldRetry(err error) (bool, time.Duration) {
+	return strings.Contains(err.Error(), "transient"), time.Millisecond
+}
+
+func TestHandlerRunningJob(t *testing.T) {
+	t.Parallel()
+
+	// steps change state and report progress, while snapshots and graphs are served.
+	jd := asyncjob.NewJobDefinition[string]("runningJob")
+	retries := 0
+	firstTsk, err := asyncjob.AddStepWithStaticFunc(jd, "First", func(ctx context.Context) (string, error) {
+		for i := 0; i <= 100; i += 10 {
+			asyncjob.ReportProgress(ctx, float64(i), "working")
+			time.Sleep(time.Millisecond)
+		}
+		if retries++; retries < 3 {
+			return "", fmt.Errorf("transient error")
+		}
+		return "first", nil
+	}, asyncjob.WithRetry(retryTransient{}))
+	assert.NoError(t, err)
+	_, err = asyncjob.StepAfterWithStaticFunc(jd, "Second", firstTsk, func(ctx context.Context, input string) (string, error) {
+		time.Sleep(5 * time.Millisecond)
+		return input + "-second", nil
+	})
+	assert.NoError(t, err)
+
+	manager := asyncjob.NewJobManager()
+	assert.NoError(t, manager.Register(jd))
+	server := httptest.NewServer(asyncjobhttp.NewHandler(manager))
+	defer server.Close()
+
+	job, err := manager.Start(context.Background(), "runningJob", "input", asyncjob.WithJobId("job1"))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"/jobs", "/jobs/job1", "/jobs/job1/graph", "/jobs/job1/graph?format=mermaid"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			for {
+				status, _ := request(t, http.MethodGet, server.URL+path)
+				assert.Equal(t, http.StatusOK, status, path)
+				select {
+				case <-job.Done():
+					return
+				default:
+				}
+			}
+		}(path)
+	}
+	assert.NoError(t, job.Wait(context.Background()))
+	wg.Wait()
+
+	status, body := request(t, http.MethodGet, server.URL+"/jobs/job1")
+	assert.Equal(t, http.StatusOK, status)
+	snapshot := &asyncjob.JobSnapshot{}
+	assert.NoError(t, json.Unmarshal([]byte(body), snapshot))
+	assert.Equal(t, asyncjob.JobStateSucceeded, snapshot.State)
+	assert.Equal(t, 2, snapshot.Steps[0].ExecutionData.Retried.Count)
+	assert.Equal(t, float64(100), snapshot.Steps[0].Progress.Percent)
+}
+
+func request(t *testing.T, method, url string) (int, string) {
+	req, err := http.NewRequest(method, url, nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
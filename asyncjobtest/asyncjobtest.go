@@ -0,0 +1,205 @@
+// Package asyncjobtest helps unit testing a JobDefinition, without rebuilding it.
+//
+//	steps can be overridden per job instance, with stub outputs, injected errors, panics or delays,
+//	and the run can be asserted on step states, execution order and retry counts.
+package asyncjobtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/stretchr/testify/assert"
+)
+
+// StepOverride replaces or wraps the step func of a step, next runs the real step func.
+//
+//	it is invoked for each attempt, if the step has a RetryPolicy.
+type StepOverride func(ctx context.Context, next func(context.Context) (any, error)) (any, error)
+
+// StubOutput skips the step func, the step completes with output, which must be assignable to output type of the step.
+func StubOutput(output any) StepOverride {
+	return func(ctx context.Context, next func(context.Context) (any, error)) (any, error) {
+		return output, nil
+	}
+}
+
+// InjectError skips the step func, every attempt of the step fails with err.
+func InjectError(err error) StepOverride {
+	return func(ctx context.Context, next func(context.Context) (any, error)) (any, error) {
+		return nil, err
+	}
+}
+
+// InjectErrorTimes fails first times attempts of the step with err, later attempts run the step func.
+func InjectErrorTimes(times int, err error) StepOverride {
+	mutex := sync.Mutex{}
+	return func(ctx context.Context, next func(context.Context) (any, error)) (any, error) {
+		mutex.Lock()
+		inject := times > 0
+		times--
+		mutex.Unlock()
+
+		if inject {
+			return nil, err
+		}
+		return next(ctx)
+	}
+}
+
+// InjectPanic panics with value instead of running the step func.
+func InjectPanic(value any) StepOverride {
+	return func(ctx context.Context, next func(context.Context) (any, error)) (any, error) {
+		panic(value)
+	}
+}
+
+// Delay runs the step func after delay, or fails with context error if ctx is done first.
+func Delay(delay time.Duration) StepOverride {
+	return func(ctx context.Context, next func(context.Context) (any, error)) (any, error) {
+		select {
+		case <-time.After(delay):
+			return next(ctx)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Run is a job instance started by Start, with execution of each step recorded.
+type Run[T any] struct {
+	*asyncjob.JobInstance[T]
+
+	mutex      sync.Mutex
+	overrides  map[string]StepOverride
+	executions []string
+}
+
+// Start starts the job definition with input, steps in overrides are executed with their StepOverride.
+//
+//	overriding a step not in the job definition fails the test.
+func Start[T any](t testing.TB, ctx context.Context, jd *asyncjob.JobDefinition[T], input T, overrides map[string]StepOverride, jobOptions ...asyncjob.JobOptionPreparer) *Run[T] {
+	t.Helper()
+
+	for stepName := range overrides {
+		if _, ok := jd.GetStep(stepName); !ok {
+			t.Errorf("asyncjobtest: step %q to override is not in job definition %q", stepName, jd.GetName())
+		}
+	}
+
+	run := &Run[T]{overrides: overrides}
+	run.JobInstance = jd.Start(ctx, input, append(jobOptions, asyncjob.WithStepInterceptor(run.intercept))...)
+	return run
+}
+
+func (r *Run[T]) intercept(ctx context.Context, step asyncjob.StepInstanceMeta, next func(context.Context) (any, error)) (any, error) {
+	r.mutex.Lock()
+	r.executions = append(r.executions, step.GetName())
+	override, ok := r.overrides[step.GetName()]
+	r.mutex.Unlock()
+
+	if !ok {
+		return next(ctx)
+	}
+	return override(ctx, next)
+}
+
+// ExecutionOrder returns step names in the order they are executed, steps retried appear once.
+func (r *Run[T]) ExecutionOrder() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	seen := map[string]bool{}
+	var order []string
+	for _, stepName := range r.executions {
+		if !seen[stepName] {
+			seen[stepName] = true
+			order = append(order, stepName)
+		}
+	}
+
+	return order
+}
+
+// Executions returns how many times the step func (or its override) is executed, including retries.
+func (r *Run[T]) Executions(stepName string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+	for _, executed := range r.executions {
+		if executed == stepName {
+			count++
+		}
+	}
+
+	return count
+}
+
+// AssertStepStates asserts state of each step in expected.
+func (r *Run[T]) AssertStepStates(t testing.TB, expected map[string]asyncjob.StepState) bool {
+	t.Helper()
+
+	ok := true
+	for stepName, expectedState := range expected {
+		step, found := r.GetStepInstance(stepName)
+		if !assert.True(t, found, "step %q is not in job instance", stepName) {
+			ok = false
+			continue
+		}
+		ok = assert.Equal(t, expectedState, step.GetState(), "state of step %q", stepName) && ok
+	}
+
+	return ok
+}
+
+// AssertExecutionOrder asserts steps are executed, and each of them started before the next one.
+func (r *Run[T]) AssertExecutionOrder(t testing.TB, stepNames ...string) bool {
+	t.Helper()
+
+	position := map[string]int{}
+	for i, stepName := range r.ExecutionOrder() {
+		position[stepName] = i
+	}
+
+	for i, stepName := range stepNames {
+		if _, ok := position[stepName]; !ok {
+			return assert.Fail(t, "step is not executed", "step %q is not executed, executed steps: %v", stepName, r.ExecutionOrder())
+		}
+		if i > 0 && position[stepNames[i-1]] > position[stepName] {
+			return assert.Fail(t, "steps executed out of order", "step %q executed before %q, executed steps: %v", stepName, stepNames[i-1], r.ExecutionOrder())
+		}
+	}
+
+	return true
+}
+
+// AssertNotExecuted asserts the steps are not executed.
+func (r *Run[T]) AssertNotExecuted(t testing.TB, stepNames ...string) bool {
+	t.Helper()
+
+	ok := true
+	for _, stepName := range stepNames {
+		ok = assert.Zero(t, r.Executions(stepName), "step %q is executed", stepName) && ok
+	}
+
+	return ok
+}
+
+// AssertRetryCount asserts how many times the step is retried by its RetryPolicy.
+func (r *Run[T]) AssertRetryCount(t testing.TB, stepName string, count int) bool {
+	t.Helper()
+
+	step, found := r.GetStepInstance(stepName)
+	if !assert.True(t, found, "step %q is not in job instance", stepName) {
+		return false
+	}
+
+	retried := 0
+	if retryReport := step.ExecutionData().Retried; retryReport != nil {
+		retried = retryReport.Count
+	}
+	return assert.Equal(t, count, retried, "retry count of step %q", stepName)
+}
@@ -0,0 +1,130 @@
+package asyncjobtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asyncjob/asyncjobtest"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+type retryTransient struct{}
+
+func (retryTransient) ShouldRetry(err error) (bool, time.Duration) {
+	return strings.Contains(err.Error(), "transient"), time.Millisecond
+}
+
+func buildOrderJob(t *testing.T) *asyncjob.JobDefinition[string] {
+	jd := asyncjob.NewJobDefinition[string]("orderJob")
+	fetchTsk, err := asyncjob.AddStep(jd, "Fetch", func(input string) asynctask.AsyncFunc[string] {
+		return func(ctx context.Context) (string, error) { return "fetched " + input, nil }
+	}, asyncjob.WithRetry(retryTransient{}))
+	assert.NoError(t, err)
+	priceTsk, err := asyncjob.StepAfterWithStaticFunc(jd, "Price", fetchTsk, func(ctx context.Context, order string) (int, error) {
+		return len(order), nil
+	}, asyncjob.WithRetry(retryTransient{}))
+	assert.NoError(t, err)
+	_, err = asyncjob.StepAfterBothWithStaticFunc(jd, "Invoice", fetchTsk, priceTsk, func(ctx context.Context, order string, price int) (string, error) {
+		return fmt.Sprintf("%s: %d", order, price), nil
+	})
+	assert.NoError(t, err)
+
+	return jd
+}
+
+func TestStubAndRetry(t *testing.T) {
+	t.Parallel()
+
+	jd := buildOrderJob(t)
+	run := asyncjobtest.Start(t, context.Background(), jd, "order1", map[string]asyncjobtest.StepOverride{
+		"Fetch": asyncjobtest.InjectErrorTimes(2, errors.New("transient")),
+		"Price": asyncjobtest.StubOutput(42),
+	})
+	assert.NoError(t, run.Wait(context.Background()))
+
+	run.AssertStepStates(t, map[string]asyncjob.StepState{
+		"Fetch":   asyncjob.StepStateCompleted,
+		"Price":   asyncjob.StepStateCompleted,
+		"Invoice": asyncjob.StepStateCompleted,
+	})
+	run.AssertExecutionOrder(t, "Fetch", "Price", "Invoice")
+	run.AssertRetryCount(t, "Fetch", 2)
+	run.AssertRetryCount(t, "Price", 0)
+	assert.Equal(t, 3, run.Executions("Fetch"))
+
+	invoice, _ := run.GetStepInstance("Invoice")
+	result, err := invoice.Waitable().(*asynctask.Task[string]).Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "fetched order1: 42", result)
+}
+
+func TestInjectErrorPanicDelay(t *testing.T) {
+	t.Parallel()
+
+	jd := buildOrderJob(t)
+	run := asyncjobtest.Start(t, context.Background(), jd, "order1", map[string]asyncjobtest.StepOverride{
+		"Fetch": asyncjobtest.InjectError(errors.New("permanent")),
+	})
+	assert.Error(t, run.Wait(context.Background()))
+	run.AssertStepStates(t, map[string]asyncjob.StepState{
+		"Fetch": asyncjob.StepStateFailed,
+		"Price": asyncjob.StepStateSkipped,
+	})
+	run.AssertNotExecuted(t, "Price", "Invoice")
+
+	run = asyncjobtest.Start(t, context.Background(), jd, "order1", map[string]asyncjobtest.StepOverride{
+		"Fetch": asyncjobtest.Delay(10 * time.Millisecond),
+		"Price": asyncjobtest.InjectPanic("boom"),
+	})
+	err := run.Wait(context.Background())
+	assert.ErrorContains(t, err, "panic cought: boom")
+	run.AssertStepStates(t, map[string]asyncjob.StepState{
+		"Fetch": asyncjob.StepStateCompleted,
+		"Price": asyncjob.StepStateFailed,
+	})
+	fetch, _ := run.GetStepInstance("Fetch")
+	assert.GreaterOrEqual(t, fetch.ExecutionData().Duration, 10*time.Millisecond)
+
+	run = asyncjobtest.Start(t, context.Background(), jd, "order1", map[string]asyncjobtest.StepOverride{
+		"Price": asyncjobtest.StubOutput("not an int"),
+	})
+	assert.ErrorIs(t, run.Wait(context.Background()), asyncjob.ErrInterceptorOutputTypeMismatch)
+}
+
+func TestFakeClockRetry(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	jd := buildOrderJob(t)
+	run := asyncjobtest.Start(t, context.Background(), jd, "order1", map[string]asyncjobtest.StepOverride{
+		"Fetch": asyncjobtest.InjectErrorTimes(2, errors.New("transient")),
+	}, asyncjob.WithClock(clock))
+
+	// retry backoff only elapses when clock is advanced.
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+	}
+	assert.NoError(t, run.Wait(context.Background()))
+	run.AssertRetryCount(t, "Fetch", 2)
+
+	fetch, _ := run.GetStepInstance("Fetch")
+	assert.Equal(t, 2*time.Minute, fetch.ExecutionData().Duration)
+	assert.Equal(t, time.Minute, fetch.ExecutionData().Retried.Attempts[1].StartTime.Sub(fetch.ExecutionData().StartTime))
+	assert.Equal(t, 2*time.Minute, run.ExecutionData().Duration)
+
+	// entries of LRUCache expire by the clock as well.
+	cache := asyncjob.NewLRUCacheWithClock(10, clock)
+	cache.Set("key", "value", time.Minute)
+	_, ok := cache.Get("key")
+	assert.True(t, ok)
+	clock.Advance(time.Minute + time.Second)
+	_, ok = cache.Get("key")
+	assert.False(t, ok)
+}
@@ -0,0 +1,63 @@
+package asyncjob
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// CaptureRedactFunc replaces sensitive parts of a captured value, before it is encoded.
+//
+//	stepName is the step taking the value as input or returning it as output, or the job name for the job input.
+type CaptureRedactFunc func(stepName string, value any) any
+
+// CapturePolicy records job input, inputs and output of each step, for debugging a run.
+type CapturePolicy struct {
+	// Redact is applied on each value before it is encoded, nil to capture values as they are.
+	Redact CaptureRedactFunc
+	// MaxSize in bytes of each encoded value, longer values are truncated, 0 for no limit.
+	MaxSize int
+}
+
+// StepCapture is the inputs and output of a step, encoded as JSON (or %+v if not JSON encodable).
+type StepCapture struct {
+	// Inputs taken from preceding steps, in parameter order, empty for AddStep.
+	Inputs []string
+	// Output of a completed step.
+	Output string
+}
+
+// WithCapture records job input, inputs and output of each step into execution data,
+//
+//	they are visible in Snapshot() and RenderTimeline(). values are passed through redact (can be nil) and truncated to maxSize bytes (0 for no limit).
+func WithCapture(redact CaptureRedactFunc, maxSize int) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.CapturePolicy = &CapturePolicy{Redact: redact, MaxSize: maxSize}
+		return options
+	}
+}
+
+// capture encodes a value, after redaction and truncation.
+func (cp *CapturePolicy) capture(stepName string, value any) string {
+	if cp.Redact != nil {
+		value = cp.Redact(stepName, value)
+	}
+
+	var captured string
+	if encoded, err := json.Marshal(value); err == nil {
+		captured = string(encoded)
+	} else {
+		captured = fmt.Sprintf("%+v", value)
+	}
+
+	if cp.MaxSize > 0 && len(captured) > cp.MaxSize {
+		// back off to a rune boundary, so a multi-byte character is not split.
+		size := cp.MaxSize
+		for size > 0 && !utf8.RuneStart(captured[size]) {
+			size--
+		}
+		captured = fmt.Sprintf("%s...(%d bytes truncated)", captured[:size], len(captured)-size)
+	}
+
+	return captured
+}
@@ -0,0 +1,65 @@
+package asyncjob_test
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobCapture(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[string]("captureJob")
+	tokenTsk, err := asyncjob.AddStepWithStaticFunc(jd, "GetToken", func(ctx context.Context) (string, error) { return "secret", nil })
+	assert.NoError(t, err)
+	_, err = asyncjob.StepAfterWithStaticFunc(jd, "ListItems", tokenTsk, func(ctx context.Context, token string) ([]int, error) {
+		return []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, nil
+	})
+	assert.NoError(t, err)
+
+	redact := func(stepName string, value any) any {
+		if value == "secret" {
+			return "***"
+		}
+		return value
+	}
+	jobInstance := jd.Start(context.Background(), "input", asyncjob.WithCapture(redact, 10))
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+
+	snapshot := jobInstance.Snapshot()
+	assert.Equal(t, `"input"`, snapshot.ExecutionData.CapturedInput)
+	assert.Equal(t, "GetToken", snapshot.Steps[0].Name)
+	assert.Equal(t, `"***"`, snapshot.Steps[0].ExecutionData.Captured.Output)
+	assert.Equal(t, "ListItems", snapshot.Steps[1].Name)
+	assert.Equal(t, []string{`"***"`}, snapshot.Steps[1].ExecutionData.Captured.Inputs)
+	assert.Equal(t, `[1,2,3,4,5...(12 bytes truncated)`, snapshot.Steps[1].ExecutionData.Captured.Output)
+
+	timeline, err := jobInstance.RenderTimeline()
+	assert.NoError(t, err)
+	assert.Contains(t, timeline, "Captured values")
+	assert.Contains(t, timeline, "<pre>&#34;***&#34;</pre>")
+
+	// not captured by default
+	jobInstance = jd.Start(context.Background(), "input")
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+	assert.Nil(t, jobInstance.Snapshot().Steps[0].ExecutionData.Captured)
+}
+
+func TestCaptureTruncateRuneBoundary(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[string]("captureRuneJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Greet", func(ctx context.Context) (string, error) { return "héllo", nil })
+	assert.NoError(t, err)
+
+	// limit falls in the middle of "é", which is 2 bytes.
+	jobInstance := jd.Start(context.Background(), "input", asyncjob.WithCapture(nil, 3))
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+
+	output := jobInstance.Snapshot().Steps[0].ExecutionData.Captured.Output
+	assert.Equal(t, `"h...(6 bytes truncated)`, output)
+	assert.True(t, utf8.ValidString(output))
+}
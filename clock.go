@@ -0,0 +1,38 @@
+package asyncjob
+
+import (
+	"time"
+)
+
+// Clock tells time and waits for durations to elapse, a fake clock can be injected to make time-based tests deterministic.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// After waits for the duration to elapse, then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is the Clock backed by package time, used by default.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock use clock for execution data, retry backoff and other time-based behavior of the job instance,
+//
+//	it overrides the default clock of the job definition.
+func WithClock(clock Clock) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.Clock = clock
+		return options
+	}
+}
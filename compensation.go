@@ -0,0 +1,109 @@
+package asyncjob
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// StepCompensation undoes a completed step, with output of the step.
+type StepCompensation func(ctx context.Context, output any) error
+
+// CompensationReport records execution of the compensation of a step.
+type CompensationReport struct {
+	StepName  string
+	StartTime time.Time
+	Duration  time.Duration
+	// error message, empty if compensation succeeded.
+	Error string
+}
+
+// WithCompensation undoes the step if the job fails after the step completed, compensate is invoked with output of the step.
+//
+//	compensations of completed steps run one by one in reverse topological order, before Wait returns.
+//	ST must be the output type of the step, otherwise compensation fails with ErrCompensationOutputTypeMismatch.
+func WithCompensation[ST any](compensate func(ctx context.Context, output ST) error) ExecutionOptionPreparer {
+	return func(options *StepExecutionOptions) *StepExecutionOptions {
+		options.Compensation = func(ctx context.Context, output any) error {
+			typedOutput, ok := output.(ST)
+			if !ok {
+				return ErrCompensationOutputTypeMismatch.WithMessage(fmt.Sprintf(MsgCompensationOutputTypeMismatch, typeName[ST](), output))
+			}
+			return compensate(ctx, typedOutput)
+		}
+		return options
+	}
+}
+
+// compensate runs compensation of a completed step, nil if the step is not completed or have no compensation.
+func (si *StepInstance[T]) compensate(ctx context.Context) *CompensationReport {
+	compensation := si.Definition.executionOptions.Compensation
+	if compensation == nil {
+		return nil
+	}
+
+	completed := si.getCompletedStep()
+	if completed == nil {
+		return nil
+	}
+
+	clock := si.JobInstance.getJobOptions().Clock
+	report := &CompensationReport{StepName: si.GetName(), StartTime: clock.Now()}
+	err := func() (err error) {
+		// handle panic from user code
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic cought: %v, StackTrace: %s", r, debug.Stack())
+			}
+		}()
+
+		return compensation(ctx, completed.output)
+	}()
+	report.Duration = clock.Since(report.StartTime)
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	si.updateExecutionData(func(executionData *StepExecutionData) { executionData.Compensation = report })
+	return report
+}
+
+// compensate runs compensations of completed steps, in reverse topological order.
+//
+//	ctx is detached from cancellation of the job, as the job could fail for being cancelled.
+func (ji *JobInstance[T]) compensate(ctx context.Context) {
+	ctx = detachedContext{ctx}
+	orderedSteps := ji.stepsDag.TopologicalSort()
+	for i := len(orderedSteps) - 1; i >= 0; i-- {
+		if report := orderedSteps[i].compensate(ctx); report != nil {
+			ji.mutex.Lock()
+			ji.executionData.Compensations = append(ji.executionData.Compensations, report)
+			ji.mutex.Unlock()
+		}
+	}
+}
+
+func compensationTooltip(report *CompensationReport) string {
+	if report == nil {
+		return ""
+	}
+
+	if report.Error != "" {
+		return "\nCompensation failed: " + report.Error
+	}
+	return "\nCompensated: " + report.Duration.String()
+}
+
+// detachedContext keeps values of the parent context, without its deadline and cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
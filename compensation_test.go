@@ -0,0 +1,101 @@
+package asyncjob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompensation(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	var compensated []string
+	compensate := func(ctx context.Context, resource string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		compensated = append(compensated, resource)
+		if resource == "disk" {
+			return errors.New("disk is in use")
+		}
+		return nil
+	}
+	create := func(resource string) func(bool) asynctask.AsyncFunc[string] {
+		return func(bool) asynctask.AsyncFunc[string] {
+			return func(ctx context.Context) (string, error) { return resource, nil }
+		}
+	}
+
+	jd := asyncjob.NewJobDefinition[bool]("provisionJob")
+	network, err := asyncjob.AddStep(jd, "CreateNetwork", create("network"), asyncjob.WithCompensation(compensate))
+	assert.NoError(t, err)
+	vm, err := asyncjob.StepAfter(jd, "CreateVM", network, func(bool) asynctask.ContinueFunc[string, string] {
+		return func(ctx context.Context, network string) (string, error) { return "vm", nil }
+	}, asyncjob.WithCompensation(compensate))
+	assert.NoError(t, err)
+	_, err = asyncjob.AddStep(jd, "CreateDisk", create("disk"), asyncjob.WithCompensation(compensate))
+	assert.NoError(t, err)
+	_, err = asyncjob.StepAfter(jd, "Configure", vm, func(fail bool) asynctask.ContinueFunc[string, string] {
+		return func(ctx context.Context, vm string) (string, error) {
+			if fail {
+				return "", fmt.Errorf("configure %s failed", vm)
+			}
+			return vm, nil
+		}
+	}, asyncjob.ExecuteAfter(network))
+	assert.NoError(t, err)
+
+	// no compensation if the job succeeded.
+	jobInstance := jd.Start(context.Background(), false)
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+	assert.Empty(t, compensated)
+	assert.Empty(t, jobInstance.ExecutionData().Compensations)
+
+	jobInstance = jd.Start(context.Background(), true)
+	err = jobInstance.Wait(context.Background())
+	assert.ErrorContains(t, err, `compensation failed: step "CreateDisk": disk is in use`)
+	jobErr := &asyncjob.JobError{}
+	if assert.True(t, errors.As(err, &jobErr)) {
+		assert.Equal(t, asyncjob.ErrStepFailed, jobErr.Code)
+		assert.Equal(t, "Configure", jobErr.StepInstance.GetName())
+		assert.Equal(t, jobInstance.ExecutionData().Compensations, jobErr.Compensations)
+	}
+
+	// CreateVM before CreateNetwork, CreateDisk is independent.
+	assert.Len(t, compensated, 3)
+	assert.Less(t, indexOf(compensated, "vm"), indexOf(compensated, "network"))
+
+	reports := map[string]*asyncjob.CompensationReport{}
+	for _, report := range jobInstance.ExecutionData().Compensations {
+		reports[report.StepName] = report
+	}
+	assert.Len(t, reports, 3)
+	assert.Empty(t, reports["CreateVM"].Error)
+	assert.Equal(t, "disk is in use", reports["CreateDisk"].Error)
+
+	disk, _ := jobInstance.GetStepInstance("CreateDisk")
+	assert.Equal(t, reports["CreateDisk"], disk.ExecutionData().Compensation)
+	assert.Contains(t, disk.DotSpec().Tooltip, "Compensation failed: disk is in use")
+
+	// compensated steps are executed again by RetryFailed.
+	retried, err := jobInstance.RetryFailed(context.Background())
+	assert.NoError(t, err)
+	assert.Error(t, retried.Wait(context.Background()))
+	assert.Len(t, retried.ExecutionData().Compensations, 3)
+	assert.Len(t, compensated, 6)
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,153 @@
+package asyncjob
+
+import (
+	"fmt"
+	"time"
+)
+
+// CriticalPathReport tells which steps decided the wall clock time of a job instance.
+//
+//	it is computed with critical path method over the DAG, using step durations from StepExecutionData.
+type CriticalPathReport struct {
+	// Path are the step names on the critical path, in execution order.
+	Path []string
+	// PathDuration is sum of step durations on the critical path.
+	PathDuration time.Duration
+	// JobDuration is the wall clock duration of the job instance.
+	JobDuration time.Duration
+	// TotalStepDuration is sum of all step durations.
+	TotalStepDuration time.Duration
+	// Parallelism achieved, TotalStepDuration / JobDuration.
+	Parallelism float64
+	// Steps have timing of each step, keyed by step name.
+	Steps map[string]*StepTimingReport
+}
+
+// StepTimingReport is the timing of a step, offsets are relative to job start, assuming each step starts as soon as it could.
+type StepTimingReport struct {
+	StepName string
+	Duration time.Duration
+	// RateLimitWait is the part of Duration waited for the rate limiter, see WithRateLimit.
+	RateLimitWait time.Duration
+	EarliestStart time.Duration
+	LatestStart   time.Duration
+	// Slack is how much the step can be delayed, without delaying the job.
+	Slack    time.Duration
+	Critical bool
+}
+
+// AnalyzeCriticalPath computes critical path, slack of each step and parallelism of a finished job instance.
+func (ji *JobInstance[T]) AnalyzeCriticalPath() (*CriticalPathReport, error) {
+	if state := ji.GetState(); !state.IsTerminal() {
+		return nil, ErrJobNotFinished.WithMessage(fmt.Sprintf(MsgJobNotFinished, ji.GetJobInstanceId(), state))
+	}
+
+	return ji.criticalPath(), nil
+}
+
+func (ji *JobInstance[T]) criticalPath() *CriticalPathReport {
+	report := &CriticalPathReport{
+		JobDuration: ji.ExecutionData().Duration,
+		Steps:       map[string]*StepTimingReport{},
+	}
+
+	// topological order, so preceding steps are always visited first.
+	var orderedSteps []StepDefinitionMeta
+	children := map[string][]string{}
+	for _, stepDef := range ji.Definition.stepsDag.TopologicalSort() {
+		if stepDef == ji.Definition.getRootStep() {
+			continue
+		}
+		orderedSteps = append(orderedSteps, stepDef)
+		for _, precedingName := range ji.precedingSteps(stepDef) {
+			children[precedingName] = append(children[precedingName], stepDef.GetName())
+		}
+	}
+
+	// forward pass: earliest start.
+	var pathEnd *StepTimingReport
+	for _, stepDef := range orderedSteps {
+		timing := &StepTimingReport{StepName: stepDef.GetName()}
+		if step, ok := ji.steps[stepDef.GetName()]; ok {
+			executionData := step.ExecutionData()
+			timing.Duration = executionData.Duration
+			timing.RateLimitWait = executionData.RateLimitWait
+		}
+		for _, precedingName := range ji.precedingSteps(stepDef) {
+			preceding := report.Steps[precedingName]
+			if end := preceding.EarliestStart + preceding.Duration; end > timing.EarliestStart {
+				timing.EarliestStart = end
+			}
+		}
+		report.Steps[timing.StepName] = timing
+		report.TotalStepDuration += timing.Duration
+
+		if pathEnd == nil || timing.EarliestStart+timing.Duration > pathEnd.EarliestStart+pathEnd.Duration {
+			pathEnd = timing
+		}
+	}
+
+	if pathEnd == nil {
+		return report
+	}
+	report.PathDuration = pathEnd.EarliestStart + pathEnd.Duration
+
+	// backward pass: latest start.
+	for i := len(orderedSteps) - 1; i >= 0; i-- {
+		timing := report.Steps[orderedSteps[i].GetName()]
+		latestEnd := report.PathDuration
+		for _, childName := range children[timing.StepName] {
+			if childStart := report.Steps[childName].LatestStart; childStart < latestEnd {
+				latestEnd = childStart
+			}
+		}
+		timing.LatestStart = latestEnd - timing.Duration
+		timing.Slack = timing.LatestStart - timing.EarliestStart
+		timing.Critical = timing.Slack == 0
+	}
+
+	// walk back from the step finished last, through the preceding step it was waiting on.
+	for current := pathEnd; current != nil; {
+		report.Path = append([]string{current.StepName}, report.Path...)
+
+		stepDef, _ := ji.Definition.GetStep(current.StepName)
+		var waitingOn *StepTimingReport
+		for _, precedingName := range ji.precedingSteps(stepDef) {
+			preceding := report.Steps[precedingName]
+			if preceding.Critical && preceding.EarliestStart+preceding.Duration == current.EarliestStart {
+				waitingOn = preceding
+				break
+			}
+		}
+		current = waitingOn
+	}
+
+	if report.JobDuration > 0 {
+		report.Parallelism = float64(report.TotalStepDuration) / float64(report.JobDuration)
+	}
+
+	return report
+}
+
+// precedingSteps returns names of preceding steps, excluding the root step.
+func (ji *JobInstance[T]) precedingSteps(stepDef StepDefinitionMeta) []string {
+	var preceding []string
+	for _, precedingName := range stepDef.DependsOn() {
+		if precedingName != ji.Definition.getRootStep().GetName() {
+			preceding = append(preceding, precedingName)
+		}
+	}
+
+	return preceding
+}
+
+// onPath returns true if both steps are on the critical path, and stepTo follows stepFrom directly.
+func (r *CriticalPathReport) onPath(stepFrom, stepTo string) bool {
+	for i := 1; i < len(r.Path); i++ {
+		if r.Path[i-1] == stepFrom && r.Path[i] == stepTo {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,60 @@
+package asyncjob_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCriticalPath(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[string]("criticalPathJob")
+	slowTsk, err := asyncjob.AddStepWithStaticFunc(jd, "Slow", sleepStepFunc(20*time.Millisecond))
+	assert.NoError(t, err)
+	fastTsk, err := asyncjob.AddStepWithStaticFunc(jd, "Fast", sleepStepFunc(time.Millisecond))
+	assert.NoError(t, err)
+	_, err = asyncjob.StepAfterBothWithStaticFunc(jd, "Merge", slowTsk, fastTsk, func(ctx context.Context, slow, fast string) (string, error) {
+		time.Sleep(5 * time.Millisecond)
+		return slow + fast, nil
+	})
+	assert.NoError(t, err)
+
+	jobInstance := jd.Start(context.Background(), "input")
+	_, err = jobInstance.AnalyzeCriticalPath()
+	assert.ErrorIs(t, err, asyncjob.ErrJobNotFinished)
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+
+	report, err := jobInstance.AnalyzeCriticalPath()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Slow", "Merge"}, report.Path)
+	assert.Equal(t, report.Steps["Slow"].Duration+report.Steps["Merge"].Duration, report.PathDuration)
+	assert.True(t, report.Steps["Slow"].Critical)
+	assert.Equal(t, time.Duration(0), report.Steps["Slow"].Slack)
+	assert.False(t, report.Steps["Fast"].Critical)
+	assert.Equal(t, report.Steps["Slow"].Duration-report.Steps["Fast"].Duration, report.Steps["Fast"].Slack)
+	assert.Equal(t, report.Steps["Slow"].Duration, report.Steps["Merge"].EarliestStart)
+	assert.Greater(t, report.Parallelism, 0.0)
+
+	graphStr, err := jobInstance.Visualize(asyncjob.WithCriticalPathHighlight())
+	assert.NoError(t, err)
+	for _, line := range strings.Split(graphStr, "\n") {
+		if strings.Contains(line, `"Slow" -> "Merge"`) {
+			assert.Contains(t, line, `color="blue"`)
+		}
+		if strings.Contains(line, `"Fast" -> "Merge"`) {
+			assert.NotContains(t, line, `color="blue"`)
+		}
+	}
+}
+
+func sleepStepFunc(duration time.Duration) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		time.Sleep(duration)
+		return duration.String(), nil
+	}
+}
@@ -0,0 +1,183 @@
+package asyncjob
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JobDescription is a serializable description of a JobDefinition, for offline tooling like diffing, linting and docs generation.
+//
+//	it can be encoded to and decoded from JSON or YAML.
+type JobDescription struct {
+	Name      string `json:"name" yaml:"name"`
+	InputType string `json:"inputType" yaml:"inputType"`
+	// Steps sorted by name, including the root step.
+	Steps []*StepDescription `json:"steps" yaml:"steps"`
+	// DataEdges are edges where the step takes output of the preceding step as input (StepAfter, StepAfterBoth).
+	DataEdges []*EdgeDescription `json:"dataEdges,omitempty" yaml:"dataEdges,omitempty"`
+	// OrderEdges are edges where the step only runs after the preceding step (ExecuteAfter, or the root step).
+	OrderEdges []*EdgeDescription `json:"orderEdges,omitempty" yaml:"orderEdges,omitempty"`
+}
+
+// StepDescription is a serializable description of a StepDefinition.
+type StepDescription struct {
+	Name string `json:"name" yaml:"name"`
+	// Kind is one of root, task, after, afterBoth, signal, finally.
+	Kind        string   `json:"kind" yaml:"kind"`
+	InputTypes  []string `json:"inputTypes,omitempty" yaml:"inputTypes,omitempty"`
+	OutputType  string   `json:"outputType" yaml:"outputType"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Owner       string   `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// RetryPolicy is the type name of the RetryPolicy, empty if not retried.
+	RetryPolicy       string `json:"retryPolicy,omitempty" yaml:"retryPolicy,omitempty"`
+	ContextEnrichment bool   `json:"contextEnrichment,omitempty" yaml:"contextEnrichment,omitempty"`
+	// CacheTTL is the TTL of cached output, empty if not cached.
+	CacheTTL string `json:"cacheTTL,omitempty" yaml:"cacheTTL,omitempty"`
+	// RateLimiter is name of the rate limiter, empty if not rate limited.
+	RateLimiter string `json:"rateLimiter,omitempty" yaml:"rateLimiter,omitempty"`
+}
+
+// EdgeDescription is an edge between 2 steps, by step name.
+type EdgeDescription struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// Describe returns a serializable description of the job definition: steps, their types and options, and edges.
+func (jd *JobDefinition[T]) Describe() *JobDescription {
+	description := &JobDescription{
+		Name:      jd.GetName(),
+		InputType: typeName[T](),
+	}
+
+	stepDescriptions := map[string]*StepDescription{}
+	for _, step := range jd.steps {
+		stepDescription := step.describe()
+		stepDescriptions[step.GetName()] = stepDescription
+		description.Steps = append(description.Steps, stepDescription)
+	}
+	sort.Slice(description.Steps, func(i, j int) bool { return description.Steps[i].Name < description.Steps[j].Name })
+
+	for _, stepDescription := range description.Steps {
+		step := jd.steps[stepDescription.Name]
+		for _, inputStep := range step.InputSteps() {
+			stepDescription.InputTypes = append(stepDescription.InputTypes, stepDescriptions[inputStep].OutputType)
+			description.DataEdges = append(description.DataEdges, &EdgeDescription{From: inputStep, To: step.GetName()})
+		}
+		for _, precedingStep := range step.DependsOn() {
+			if step.DependencyKind(precedingStep) == EdgeKindOrder {
+				description.OrderEdges = append(description.OrderEdges, &EdgeDescription{From: precedingStep, To: step.GetName()})
+			}
+		}
+	}
+	sortEdges(description.DataEdges)
+	sortEdges(description.OrderEdges)
+
+	return description
+}
+
+// DiffJobDescriptions returns human readable differences from oldDescription to newDescription, sorted, empty if they are same.
+func DiffJobDescriptions(oldDescription, newDescription *JobDescription) []string {
+	var diffs []string
+	if oldDescription.Name != newDescription.Name {
+		diffs = append(diffs, fmt.Sprintf("job name changed from %q to %q", oldDescription.Name, newDescription.Name))
+	}
+	if oldDescription.InputType != newDescription.InputType {
+		diffs = append(diffs, fmt.Sprintf("job input type changed from %q to %q", oldDescription.InputType, newDescription.InputType))
+	}
+
+	oldSteps := map[string]*StepDescription{}
+	for _, step := range oldDescription.Steps {
+		oldSteps[step.Name] = step
+	}
+	newSteps := map[string]*StepDescription{}
+	for _, step := range newDescription.Steps {
+		newSteps[step.Name] = step
+		oldStep, ok := oldSteps[step.Name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("step %q is added", step.Name))
+			continue
+		}
+		diffs = append(diffs, diffStepDescriptions(oldStep, step)...)
+	}
+	for _, step := range oldDescription.Steps {
+		if _, ok := newSteps[step.Name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("step %q is removed", step.Name))
+		}
+	}
+
+	diffs = append(diffs, diffEdges("data", oldDescription.DataEdges, newDescription.DataEdges)...)
+	diffs = append(diffs, diffEdges("order", oldDescription.OrderEdges, newDescription.OrderEdges)...)
+
+	sort.Strings(diffs)
+	return diffs
+}
+
+func diffStepDescriptions(oldStep, newStep *StepDescription) []string {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"kind", oldStep.Kind, newStep.Kind},
+		{"input types", strings.Join(oldStep.InputTypes, ", "), strings.Join(newStep.InputTypes, ", ")},
+		{"output type", oldStep.OutputType, newStep.OutputType},
+		{"description", oldStep.Description, newStep.Description},
+		{"owner", oldStep.Owner, newStep.Owner},
+		{"tags", strings.Join(oldStep.Tags, ", "), strings.Join(newStep.Tags, ", ")},
+		{"retry policy", oldStep.RetryPolicy, newStep.RetryPolicy},
+		{"context enrichment", fmt.Sprint(oldStep.ContextEnrichment), fmt.Sprint(newStep.ContextEnrichment)},
+		{"cache TTL", oldStep.CacheTTL, newStep.CacheTTL},
+		{"rate limiter", oldStep.RateLimiter, newStep.RateLimiter},
+	}
+
+	var diffs []string
+	for _, field := range fields {
+		if field.old != field.new {
+			diffs = append(diffs, fmt.Sprintf("step %q %s changed from %q to %q", newStep.Name, field.name, field.old, field.new))
+		}
+	}
+
+	return diffs
+}
+
+func diffEdges(kind string, oldEdges, newEdges []*EdgeDescription) []string {
+	oldSet := map[EdgeDescription]bool{}
+	for _, edge := range oldEdges {
+		oldSet[*edge] = true
+	}
+	newSet := map[EdgeDescription]bool{}
+	for _, edge := range newEdges {
+		newSet[*edge] = true
+	}
+
+	var diffs []string
+	for edge := range newSet {
+		if !oldSet[edge] {
+			diffs = append(diffs, fmt.Sprintf("%s edge %q -> %q is added", kind, edge.From, edge.To))
+		}
+	}
+	for edge := range oldSet {
+		if !newSet[edge] {
+			diffs = append(diffs, fmt.Sprintf("%s edge %q -> %q is removed", kind, edge.From, edge.To))
+		}
+	}
+
+	return diffs
+}
+
+func sortEdges(edges []*EdgeDescription) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
+
+// typeName returns name of type T, like "*asyncjob.JobInstance[string]".
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
@@ -0,0 +1,77 @@
+package asyncjob_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	description := SqlSummaryAsyncJobDefinition.Describe()
+	assert.Equal(t, "sqlSummaryJob", description.Name)
+	assert.Equal(t, "*asyncjob_test.SqlSummaryJobLib", description.InputType)
+
+	steps := map[string]*asyncjob.StepDescription{}
+	for _, step := range description.Steps {
+		steps[step.Name] = step
+	}
+	assert.Equal(t, "root", steps["sqlSummaryJob"].Kind)
+	assert.Equal(t, "task", steps["GetConnection"].Kind)
+	assert.Equal(t, "after", steps["QueryTable1"].Kind)
+	assert.Equal(t, []string{"*asyncjob_test.SqlTableClient"}, steps["QueryTable1"].InputTypes)
+	assert.Equal(t, "afterBoth", steps["Summarize"].Kind)
+	assert.Equal(t, "*asyncjob_test.SummarizedResult", steps["Summarize"].OutputType)
+	assert.Equal(t, "storage-team", steps["GetTableClient1"].Owner)
+	assert.True(t, steps["QueryTable1"].ContextEnrichment)
+
+	assert.Contains(t, description.DataEdges, &asyncjob.EdgeDescription{From: "GetTableClient1", To: "QueryTable1"})
+	assert.Contains(t, description.OrderEdges, &asyncjob.EdgeDescription{From: "CheckAuth", To: "QueryTable1"})
+	assert.Contains(t, description.OrderEdges, &asyncjob.EdgeDescription{From: "sqlSummaryJob", To: "GetConnection"})
+	assert.NotContains(t, description.OrderEdges, &asyncjob.EdgeDescription{From: "GetTableClient1", To: "QueryTable1"})
+
+	// round trip through json
+	encoded, err := json.Marshal(description)
+	assert.NoError(t, err)
+	decoded := &asyncjob.JobDescription{}
+	assert.NoError(t, json.Unmarshal(encoded, decoded))
+	assert.Equal(t, description, decoded)
+	assert.Empty(t, asyncjob.DiffJobDescriptions(description, decoded))
+
+	// round trip through yaml
+	encoded, err = yaml.Marshal(description)
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), "inputTypes:")
+	assert.Contains(t, string(encoded), "dataEdges:")
+	decoded = &asyncjob.JobDescription{}
+	assert.NoError(t, yaml.Unmarshal(encoded, decoded))
+	assert.Equal(t, description, decoded)
+	assert.Empty(t, asyncjob.DiffJobDescriptions(description, decoded))
+}
+
+func TestDiffJobDescriptions(t *testing.T) {
+	t.Parallel()
+
+	jd1 := asyncjob.NewJobDefinition[string]("diffJob")
+	a1, _ := asyncjob.AddStepWithStaticFunc(jd1, "A", sleepStepFunc(0))
+	b1, _ := asyncjob.AddStepWithStaticFunc(jd1, "B", sleepStepFunc(0))
+	asyncjob.StepAfterWithStaticFunc(jd1, "C", a1, func(ctx context.Context, a string) (string, error) { return a, nil }, asyncjob.ExecuteAfter(b1))
+
+	jd2 := asyncjob.NewJobDefinition[string]("diffJob")
+	a2, _ := asyncjob.AddStepWithStaticFunc(jd2, "A", sleepStepFunc(0), asyncjob.WithCache(asyncjob.NewLRUCache(10), nil, time.Minute))
+	asyncjob.StepAfterWithStaticFunc(jd2, "C", a2, func(ctx context.Context, a string) (int, error) { return len(a), nil })
+
+	assert.Equal(t, []string{
+		`order edge "B" -> "C" is removed`,
+		`order edge "diffJob" -> "B" is removed`,
+		`step "A" cache TTL changed from "" to "1m0s"`,
+		`step "B" is removed`,
+		`step "C" output type changed from "string" to "int"`,
+	}, asyncjob.DiffJobDescriptions(jd1.Describe(), jd2.Describe()))
+}
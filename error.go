@@ -3,6 +3,7 @@ package asyncjob
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 type JobErrorCode string
@@ -10,6 +11,7 @@ type JobErrorCode string
 const (
 	ErrPrecedentStepFailed JobErrorCode = "PrecedentStepFailed"
 	ErrStepFailed          JobErrorCode = "StepFailed"
+	ErrStateStoreFailed    JobErrorCode = "StateStoreFailed"
 
 	ErrRefStepNotInJob JobErrorCode = "RefStepNotInJob"
 	MsgRefStepNotInJob string       = "trying to reference to step %q, but it is not registered in job"
@@ -25,6 +27,66 @@ const (
 
 	ErrRuntimeStepNotFound JobErrorCode = "RuntimeStepNotFound"
 	MsgRuntimeStepNotFound string       = "runtime step %q not found, must be a bug in asyncjob"
+
+	ErrCheckpointNotFound JobErrorCode = "CheckpointNotFound"
+	MsgCheckpointNotFound string       = "checkpoint of job %q not found in state store"
+
+	ErrInvalidJobId JobErrorCode = "InvalidJobId"
+	MsgInvalidJobId string       = "job id %q cannot be used as a directory name"
+
+	ErrJobNotFinished JobErrorCode = "JobNotFinished"
+	MsgJobNotFinished string       = "job %q is still %s, wait for it to finish first"
+
+	ErrUnsupportedVisualizeFormat JobErrorCode = "UnsupportedVisualizeFormat"
+	MsgUnsupportedVisualizeFormat string       = "visualize format %q is not supported"
+
+	ErrCheckpointShapeMismatch JobErrorCode = "CheckpointShapeMismatch"
+	MsgCheckpointShapeMismatch string       = "job definition %q changed since checkpoint was written: %s"
+
+	ErrJobInputTypeMismatch JobErrorCode = "JobInputTypeMismatch"
+	MsgJobInputTypeMismatch string       = "job definition %q takes input of %s, got %T"
+
+	ErrJobDefinitionAlreadyRegistered JobErrorCode = "JobDefinitionAlreadyRegistered"
+	MsgJobDefinitionAlreadyRegistered string       = "job definition %q is already registered"
+
+	ErrJobDefinitionNotRegistered JobErrorCode = "JobDefinitionNotRegistered"
+	MsgJobDefinitionNotRegistered string       = "job definition %q is not registered"
+
+	ErrJobManagerShutdown JobErrorCode = "JobManagerShutdown"
+	MsgJobManagerShutdown string       = "job manager is shut down, cannot start job of %q"
+
+	ErrDuplicateJobId JobErrorCode = "DuplicateJobId"
+	MsgDuplicateJobId string       = "job %q already exists in job manager"
+
+	ErrJobRunningElsewhere JobErrorCode = "JobRunningElsewhere"
+	MsgJobRunningElsewhere string       = "job %q is not finished in state store, it could be running in another process, or left behind by a crash"
+
+	ErrInterceptorOutputTypeMismatch JobErrorCode = "InterceptorOutputTypeMismatch"
+	MsgInterceptorOutputTypeMismatch string       = "interceptor returned %T for step %q, expecting %s"
+
+	ErrStepNotSignalable JobErrorCode = "StepNotSignalable"
+	MsgStepNotSignalable string       = "step %q is not added by WaitForSignal, cannot be signaled"
+
+	ErrSignalTypeMismatch JobErrorCode = "SignalTypeMismatch"
+	MsgSignalTypeMismatch string       = "step %q takes signal of %s, got %T"
+
+	ErrSignalNotAccepted JobErrorCode = "SignalNotAccepted"
+	MsgSignalNotAccepted string       = "step %q cannot accept signal, it is %s"
+
+	ErrSignalTimeout JobErrorCode = "SignalTimeout"
+	MsgSignalTimeout string       = "step %q is not signaled within %s"
+
+	ErrCompensationOutputTypeMismatch JobErrorCode = "CompensationOutputTypeMismatch"
+	MsgCompensationOutputTypeMismatch string       = "compensation takes output of %s, got %T"
+
+	ErrInvalidRateLimit JobErrorCode = "InvalidRateLimit"
+	MsgInvalidRateLimit string       = "rate limiter %q needs positive rate and burst, got rate %v, burst %d"
+
+	ErrRateLimiterAlreadyRegistered JobErrorCode = "RateLimiterAlreadyRegistered"
+	MsgRateLimiterAlreadyRegistered string       = "rate limiter %q is already registered"
+
+	ErrRateLimiterNotFound JobErrorCode = "RateLimiterNotFound"
+	MsgRateLimiterNotFound string       = "rate limiter %q is not registered"
 )
 
 func (code JobErrorCode) Error() string {
@@ -53,6 +115,8 @@ type JobError struct {
 	StepError    error
 	StepInstance StepInstanceMeta
 	Message      string
+	// Compensations ran after the job failed, only set on the error returned by JobInstance.Wait.
+	Compensations []*CompensationReport
 }
 
 func newStepError(code JobErrorCode, step StepInstanceMeta, stepErr error) *JobError {
@@ -60,12 +124,34 @@ func newStepError(code JobErrorCode, step StepInstanceMeta, stepErr error) *JobE
 }
 
 func (je *JobError) Error() string {
+	return je.error() + compensationErrors(je.Compensations)
+}
+
+func (je *JobError) error() string {
 	if je.Code == ErrStepFailed && je.StepError != nil {
 		return fmt.Sprintf("step %q failed: %s", je.StepInstance.GetName(), je.StepError.Error())
 	}
+	if je.Code == ErrStateStoreFailed && je.StepError != nil {
+		return fmt.Sprintf("step %q failed to save state: %s", je.StepInstance.GetName(), je.StepError.Error())
+	}
 	return je.Code.Error() + ": " + je.Message
 }
 
+// compensationErrors describes failed compensations, empty if all succeeded.
+func compensationErrors(reports []*CompensationReport) string {
+	var failed []string
+	for _, report := range reports {
+		if report.Error != "" {
+			failed = append(failed, fmt.Sprintf("step %q: %s", report.StepName, report.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(", compensation failed: %s", strings.Join(failed, "; "))
+}
+
 func (je *JobError) Unwrap() error {
 	return je.StepError
 }
@@ -73,7 +159,7 @@ func (je *JobError) Unwrap() error {
 // RootCause track precendent chain and return the first step raised this error.
 func (je *JobError) RootCause() error {
 	// this step failed, return the error
-	if je.Code == ErrStepFailed {
+	if je.Code == ErrStepFailed || je.Code == ErrStateStoreFailed {
 		return je
 	}
 
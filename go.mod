@@ -3,14 +3,14 @@ module github.com/Azure/go-asyncjob
 go 1.20
 
 require (
-	github.com/Azure/go-asyncjob/graph v0.2.0
+	github.com/Azure/go-asyncjob/graph v0.3.0
 	github.com/Azure/go-asynctask v1.6.0
 	github.com/google/uuid v1.4.0
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
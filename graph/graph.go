@@ -3,6 +3,7 @@ package graph
 import (
 	"bytes"
 	"fmt"
+	"sort"
 )
 
 // NodeConstrain is a constraint for a node in a graph
@@ -31,6 +32,30 @@ type DotNodeSpec struct {
 	Shape       string
 	Style       string
 	FillColor   string
+	// name of the cluster (subgraph) this node belongs to, empty for none.
+	Cluster string
+	// additional DOT attributes, rendered after the fields above.
+	Attributes map[string]string
+}
+
+// DotGraphSpec is the specification for graph level attributes in DOT graph
+type DotGraphSpec struct {
+	// label displayed for the whole graph
+	Label string
+	// direction of the graph layout: TB, LR, BT, RL
+	RankDir string
+	// clusters (subgraphs) referenced by DotNodeSpec.Cluster
+	Clusters []*DotClusterSpec
+	// additional DOT attributes on graph level.
+	Attributes map[string]string
+}
+
+// DotClusterSpec is the specification for a cluster (subgraph) in DOT graph
+type DotClusterSpec struct {
+	Name  string
+	Label string
+	// additional DOT attributes on the cluster.
+	Attributes map[string]string
 }
 
 // DotEdgeSpec is the specification for an edge in DOT graph
@@ -40,6 +65,8 @@ type DotEdgeSpec struct {
 	Tooltip      string
 	Style        string
 	Color        string
+	// additional DOT attributes, rendered after the fields above.
+	Attributes map[string]string
 }
 
 // Graph hold the nodes and edges of a graph
@@ -58,6 +85,15 @@ func NewGraph[NT NodeConstrain](edgeSpecFunc EdgeSpecFunc[NT]) *Graph[NT] {
 	}
 }
 
+// WithEdgeSpecFunc returns a view of the graph sharing same nodes and edges, with edges rendered by edgeSpecFunc.
+func (g *Graph[NT]) WithEdgeSpecFunc(edgeSpecFunc EdgeSpecFunc[NT]) *Graph[NT] {
+	return &Graph[NT]{
+		nodes:        g.nodes,
+		nodeEdges:    g.nodeEdges,
+		edgeSpecFunc: edgeSpecFunc,
+	}
+}
+
 // AddNode adds a node to the graph
 func (g *Graph[NT]) AddNode(n NT) error {
 	nodeKey := n.GetName()
@@ -87,10 +123,28 @@ func (g *Graph[NT]) Connect(from, to NT) error {
 
 // https://en.wikipedia.org/wiki/DOT_(graph_description_language)
 func (g *Graph[NT]) ToDotGraph() (string, error) {
-	nodes := make([]*DotNodeSpec, 0)
+	return g.ToDotGraphWithSpec(nil)
+}
+
+// ToDotGraphWithSpec is same as ToDotGraph, with graph level attributes from graphSpec (can be nil).
+func (g *Graph[NT]) ToDotGraphWithSpec(graphSpec *DotGraphSpec) (string, error) {
+	nodes, edges := g.dotSpecs()
+
+	buf := new(bytes.Buffer)
+	err := digraphTemplate.Execute(buf, newTemplateRef(graphSpec, nodes, edges))
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// dotSpecs returns specs of all nodes and edges, sorted by name to keep output stable.
+func (g *Graph[NT]) dotSpecs() ([]*DotNodeSpec, []*DotEdgeSpec) {
+	nodes := make([]*DotNodeSpec, 0, len(g.nodes))
 	for _, node := range g.nodes {
 		nodes = append(nodes, node.DotSpec())
 	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
 
 	edges := make([]*DotEdgeSpec, 0)
 	for _, nodeEdges := range g.nodeEdges {
@@ -98,13 +152,14 @@ func (g *Graph[NT]) ToDotGraph() (string, error) {
 			edges = append(edges, g.edgeSpecFunc(edge.From, edge.To))
 		}
 	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].FromNodeName != edges[j].FromNodeName {
+			return edges[i].FromNodeName < edges[j].FromNodeName
+		}
+		return edges[i].ToNodeName < edges[j].ToNodeName
+	})
 
-	buf := new(bytes.Buffer)
-	err := digraphTemplate.Execute(buf, templateRef{Nodes: nodes, Edges: edges})
-	if err != nil {
-		return "", err
-	}
-	return buf.String(), nil
+	return nodes, edges
 }
 
 func (g *Graph[NT]) TopologicalSort() []NT {
@@ -135,3 +135,104 @@ func edgeSpecFromConnection(from, to *testNode) *graph.DotEdgeSpec {
 		Color:        "black",
 	}
 }
+
+func TestGraphLabel(t *testing.T) {
+	g := graph.NewGraph(edgeSpecFromConnection)
+	root := &testNode{Name: "root"}
+	g.AddNode(root)
+
+	graphStr, err := g.ToDotGraphWithSpec(&graph.DotGraphSpec{Label: "job: succeeded"})
+	assert.NoError(t, err)
+	assert.Contains(t, graphStr, `label = "job: succeeded"`)
+
+	graphStr, err = g.ToDotGraph()
+	assert.NoError(t, err)
+	assert.NotContains(t, graphStr, "labelloc")
+}
+
+func TestMermaidFlowchart(t *testing.T) {
+	g := graph.NewGraph(edgeSpecFromConnection)
+	root := &testNode{Name: "root"}
+	g.AddNode(root)
+	calc1 := &testNode{Name: "calc \"1\""}
+	g.AddNode(calc1)
+	g.Connect(root, calc1)
+
+	graphStr, err := g.ToMermaidFlowchartWithSpec(&graph.DotGraphSpec{Label: "job\nsucceeded"})
+	assert.NoError(t, err)
+	t.Log(graphStr)
+
+	assert.Contains(t, graphStr, "title: \"job, succeeded\"")
+	assert.Contains(t, graphStr, "flowchart TD")
+	assert.Contains(t, graphStr, "n0[\"calc #quot;1#quot;\"]")
+	assert.Contains(t, graphStr, "style n0 fill:green")
+	assert.Contains(t, graphStr, "n1 --> n0")
+	assert.Contains(t, graphStr, "linkStyle 0 stroke:black")
+}
+
+func TestDotEscapeAndAttributes(t *testing.T) {
+	g := graph.NewGraph(func(from, to *attributedNode) *graph.DotEdgeSpec {
+		return &graph.DotEdgeSpec{
+			FromNodeName: from.GetName(),
+			ToNodeName:   to.GetName(),
+			Tooltip:      "line1\nline2",
+			Style:        "dashed",
+			Color:        "gray",
+			Attributes:   map[string]string{"penwidth": "2", "arrowhead": "empty"},
+		}
+	})
+	root := &attributedNode{Name: "root"}
+	g.AddNode(root)
+	quoted := &attributedNode{Name: `step "1"`, Cluster: "queries", Tooltip: `failed: C:\temp "not found"` + "\nretried"}
+	g.AddNode(quoted)
+	g.Connect(root, quoted)
+
+	graphStr, err := g.ToDotGraphWithSpec(&graph.DotGraphSpec{
+		Label:      "job \"1\"",
+		RankDir:    "LR",
+		Clusters:   []*graph.DotClusterSpec{{Name: "queries", Label: "Queries", Attributes: map[string]string{"style": "dashed"}}},
+		Attributes: map[string]string{"fontname": "Helvetica"},
+	})
+	assert.NoError(t, err)
+	t.Log(graphStr)
+
+	assert.Contains(t, graphStr, `label = "job \"1\""`)
+	assert.Contains(t, graphStr, `rankdir = "LR"`)
+	assert.Contains(t, graphStr, `"fontname" = "Helvetica"`)
+	assert.Contains(t, graphStr, `subgraph "cluster_queries" {`)
+	assert.Contains(t, graphStr, `label = "Queries"`)
+	assert.Contains(t, graphStr, `"step \"1\"" [label="step \"1\""`)
+	assert.Contains(t, graphStr, `tooltip="failed: C:\\temp \"not found\"\nretried"`)
+	assert.Contains(t, graphStr, `"root" [label="root" shape="box" style="filled" tooltip="" fillcolor="white" "color"="blue"]`)
+	assert.Contains(t, graphStr, `"root" -> "step \"1\"" [style="dashed" tooltip="line1\nline2" color="gray" "arrowhead"="empty" "penwidth"="2"]`)
+
+	mermaidStr, err := g.ToMermaidFlowchartWithSpec(&graph.DotGraphSpec{RankDir: "LR"})
+	assert.NoError(t, err)
+	t.Log(mermaidStr)
+	assert.Contains(t, mermaidStr, "flowchart LR")
+	assert.Contains(t, mermaidStr, "subgraph c0 [\"queries\"]")
+	assert.Contains(t, mermaidStr, "n0 -.-> n1")
+}
+
+type attributedNode struct {
+	Name    string
+	Cluster string
+	Tooltip string
+}
+
+func (n *attributedNode) GetName() string {
+	return n.Name
+}
+
+func (n *attributedNode) DotSpec() *graph.DotNodeSpec {
+	return &graph.DotNodeSpec{
+		Name:        n.Name,
+		DisplayName: n.Name,
+		Tooltip:     n.Tooltip,
+		Shape:       "box",
+		Style:       "filled",
+		FillColor:   "white",
+		Cluster:     n.Cluster,
+		Attributes:  map[string]string{"color": "blue"},
+	}
+}
@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// https://mermaid.js.org/syntax/flowchart.html
+
+// ToMermaidFlowchart renders the graph as mermaid flowchart, shapes, colors and edge styles are mapped from DotNodeSpec and DotEdgeSpec.
+func (g *Graph[NT]) ToMermaidFlowchart() (string, error) {
+	return g.ToMermaidFlowchartWithSpec(nil)
+}
+
+// ToMermaidFlowchartWithSpec is same as ToMermaidFlowchart, graphSpec.Label is rendered as title, clusters as subgraphs.
+func (g *Graph[NT]) ToMermaidFlowchartWithSpec(graphSpec *DotGraphSpec) (string, error) {
+	nodes, edges := g.dotSpecs()
+
+	// node names can have any character, mermaid ids cannot.
+	nodeIds := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		nodeIds[node.Name] = fmt.Sprintf("n%d", i)
+	}
+
+	sb := &strings.Builder{}
+	if graphSpec != nil && graphSpec.Label != "" {
+		fmt.Fprintf(sb, "---\ntitle: %s\n---\n", mermaidTitle(graphSpec.Label))
+	}
+	direction := "TD"
+	if graphSpec != nil && graphSpec.RankDir != "" {
+		direction = graphSpec.RankDir
+	}
+	fmt.Fprintf(sb, "flowchart %s\n", direction)
+
+	writeNode := func(indent string, node *DotNodeSpec) {
+		nodeId := nodeIds[node.Name]
+		openBracket, closeBracket := mermaidNodeShape(node.Shape)
+		fmt.Fprintf(sb, "%s%s%s\"%s\"%s\n", indent, nodeId, openBracket, mermaidText(node.DisplayName), closeBracket)
+		if style := mermaidNodeStyle(node); style != "" {
+			fmt.Fprintf(sb, "%sstyle %s %s\n", indent, nodeId, style)
+		}
+	}
+
+	grouped := newTemplateRef(graphSpec, nodes, edges)
+	for i, cluster := range grouped.Clusters {
+		label := cluster.Spec.Label
+		if label == "" {
+			label = cluster.Spec.Name
+		}
+		fmt.Fprintf(sb, "\tsubgraph c%d [\"%s\"]\n", i, mermaidText(label))
+		for _, node := range cluster.Nodes {
+			writeNode("\t\t", node)
+		}
+		sb.WriteString("\tend\n")
+	}
+	for _, node := range grouped.Nodes {
+		writeNode("\t", node)
+	}
+
+	for i, edge := range edges {
+		fromId, ok := nodeIds[edge.FromNodeName]
+		if !ok {
+			return "", NewGraphError(ErrConnectNotExistingNode, fmt.Sprintf("edge from node %s, it's not added in this graph", edge.FromNodeName))
+		}
+		toId, ok := nodeIds[edge.ToNodeName]
+		if !ok {
+			return "", NewGraphError(ErrConnectNotExistingNode, fmt.Sprintf("edge to node %s, it's not added in this graph", edge.ToNodeName))
+		}
+
+		fmt.Fprintf(sb, "\t%s %s %s\n", fromId, mermaidArrow(edge.Style), toId)
+		if edge.Color != "" {
+			fmt.Fprintf(sb, "\tlinkStyle %d stroke:%s\n", i, edge.Color)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func mermaidNodeShape(dotShape string) (string, string) {
+	switch dotShape {
+	case "hexagon":
+		return "{{", "}}"
+	case "triangle":
+		return "[/", "\\]"
+	case "diamond":
+		return "{", "}"
+	case "circle":
+		return "((", "))"
+	case "ellipse", "oval":
+		return "([", "])"
+	default:
+		return "[", "]"
+	}
+}
+
+func mermaidNodeStyle(node *DotNodeSpec) string {
+	var styles []string
+	if node.FillColor != "" && strings.Contains(node.Style, "filled") {
+		styles = append(styles, "fill:"+node.FillColor)
+	}
+	if strings.Contains(node.Style, "dashed") {
+		styles = append(styles, "stroke-dasharray:5 5")
+	}
+	if strings.Contains(node.Style, "bold") {
+		styles = append(styles, "stroke-width:3px")
+	}
+
+	return strings.Join(styles, ",")
+}
+
+func mermaidArrow(dotStyle string) string {
+	switch {
+	case strings.Contains(dotStyle, "dashed"), strings.Contains(dotStyle, "dotted"):
+		return "-.->"
+	case strings.Contains(dotStyle, "bold"):
+		return "==>"
+	default:
+		return "-->"
+	}
+}
+
+// mermaidText escape quotes, and convert line breaks.
+func mermaidText(text string) string {
+	text = strings.ReplaceAll(text, `"`, "#quot;")
+	return strings.ReplaceAll(text, "\n", "<br/>")
+}
+
+// mermaidTitle is in yaml front matter, it have to be single line.
+func mermaidTitle(text string) string {
+	text = strings.ReplaceAll(text, `"`, "'")
+	text = strings.ReplaceAll(text, "\n", ", ")
+	return `"` + text + `"`
+}
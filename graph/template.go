@@ -1,23 +1,117 @@
 package graph
 
 import (
+	"sort"
+	"strings"
 	"text/template"
 )
 
 // https://www.graphviz.org/docs/
 // http://magjac.com/graphviz-visual-editor/
 
-var digraphTemplate = template.Must(template.New("digraph").Parse(digraphTemplateText))
+var digraphTemplate = template.Must(template.New("digraph").Funcs(template.FuncMap{
+	"quote": dotQuote,
+	"attrs": dotAttributes,
+}).Parse(digraphTemplateText))
 
 type templateRef struct {
+	Graph    *DotGraphSpec
+	Clusters []*clusterRef
+	// nodes not in any cluster
 	Nodes []*DotNodeSpec
 	Edges []*DotEdgeSpec
 }
 
-const digraphTemplateText = `digraph {
+type clusterRef struct {
+	Spec  *DotClusterSpec
+	Nodes []*DotNodeSpec
+}
+
+// newTemplateRef groups nodes by cluster, clusters not declared in graphSpec get one without label.
+func newTemplateRef(graphSpec *DotGraphSpec, nodes []*DotNodeSpec, edges []*DotEdgeSpec) *templateRef {
+	ref := &templateRef{Graph: graphSpec, Edges: edges}
+
+	clusters := map[string]*clusterRef{}
+	if graphSpec != nil {
+		for _, clusterSpec := range graphSpec.Clusters {
+			cluster := &clusterRef{Spec: clusterSpec}
+			clusters[clusterSpec.Name] = cluster
+			ref.Clusters = append(ref.Clusters, cluster)
+		}
+	}
+
+	for _, node := range nodes {
+		if node.Cluster == "" {
+			ref.Nodes = append(ref.Nodes, node)
+			continue
+		}
+
+		cluster, ok := clusters[node.Cluster]
+		if !ok {
+			cluster = &clusterRef{Spec: &DotClusterSpec{Name: node.Cluster}}
+			clusters[node.Cluster] = cluster
+			ref.Clusters = append(ref.Clusters, cluster)
+		}
+		cluster.Nodes = append(cluster.Nodes, node)
+	}
+
+	return ref
+}
+
+// dotQuote returns s as a quoted DOT string, quotes and backslashes are escaped, newlines become DOT line breaks.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s) + `"`
+}
+
+// dotAttributes renders additional attributes sorted by key, with a leading space.
+func dotAttributes(attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sb := &strings.Builder{}
+	for _, key := range keys {
+		sb.WriteString(" " + dotQuote(key) + "=" + dotQuote(attributes[key]))
+	}
+	return sb.String()
+}
+
+const digraphTemplateText = `
+{{- define "node" }}{{ quote .Name }} [label={{ quote .DisplayName }} shape={{ quote .Shape }} style={{ quote .Style }} tooltip={{ quote .Tooltip }} fillcolor={{ quote .FillColor }}{{ attrs .Attributes }}]{{ end -}}
+digraph {
 	newrank = "true"
-{{ range $node := $.Nodes}}		"{{$node.Name}}" [label="{{$node.DisplayName}}" shape={{$node.Shape}} style={{$node.Style}} tooltip="{{$node.Tooltip}}" fillcolor={{$node.FillColor}}] 
-{{ end }}        
-{{ range $edge := $.Edges}}		"{{$edge.FromNodeName}}" -> "{{$edge.ToNodeName}}" [style={{$edge.Style}} tooltip="{{$edge.Tooltip}}" color={{$edge.Color}}] 
-{{ end }}
+{{- with $.Graph }}
+{{- if .Label }}
+	label = {{ quote .Label }}
+	labelloc = "t"
+{{- end }}
+{{- if .RankDir }}
+	rankdir = {{ quote .RankDir }}
+{{- end }}
+{{- range $key, $value := .Attributes }}
+	{{ quote $key }} = {{ quote $value }}
+{{- end }}
+{{- end }}
+{{- range $cluster := $.Clusters }}
+	subgraph {{ quote (printf "cluster_%s" $cluster.Spec.Name) }} {
+{{- if $cluster.Spec.Label }}
+		label = {{ quote $cluster.Spec.Label }}
+{{- end }}
+{{- range $key, $value := $cluster.Spec.Attributes }}
+		{{ quote $key }} = {{ quote $value }}
+{{- end }}
+{{- range $node := $cluster.Nodes }}
+		{{ template "node" $node }}
+{{- end }}
+	}
+{{- end }}
+{{- range $node := $.Nodes }}
+		{{ template "node" $node }}
+{{- end }}
+
+{{- range $edge := $.Edges }}
+		{{ quote $edge.FromNodeName }} -> {{ quote $edge.ToNodeName }} [style={{ quote $edge.Style }} tooltip={{ quote $edge.Tooltip }} color={{ quote $edge.Color }}{{ attrs $edge.Attributes }}]
+{{- end }}
 }`
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/Azure/go-asyncjob/graph"
+	"github.com/Azure/go-asynctask"
 )
 
 // Interface for a job definition
@@ -14,9 +17,10 @@ type JobDefinitionMeta interface {
 	GetStep(stepName string) (StepDefinitionMeta, bool) // TODO: switch bool to error
 	Seal()
 	Sealed() bool
-	Visualize() (string, error)
+	Visualize(...VisualizeOptionPreparer) (string, error)
 
 	// not exposing for now.
+	startUntyped(ctx context.Context, input any, jobOptions ...JobOptionPreparer) (JobInstanceMeta, error)
 	addStep(step StepDefinitionMeta, precedingSteps ...StepDefinitionMeta) error
 	getRootStep() StepDefinitionMeta
 }
@@ -29,6 +33,9 @@ type JobDefinition[T any] struct {
 	steps    map[string]StepDefinitionMeta
 	stepsDag *graph.Graph[StepDefinitionMeta]
 	rootStep *StepDefinition[T]
+
+	// default clock of job instances, without WithClock.
+	clock Clock
 }
 
 // Create new JobDefinition
@@ -39,6 +46,7 @@ func NewJobDefinition[T any](name string) *JobDefinition[T] {
 		name:     name,
 		steps:    make(map[string]StepDefinitionMeta),
 		stepsDag: graph.NewGraph(connectStepDefinition),
+		clock:    RealClock{},
 	}
 
 	rootStep := newStepDefinition[T](name, stepTypeRoot)
@@ -50,6 +58,11 @@ func NewJobDefinition[T any](name string) *JobDefinition[T] {
 	return j
 }
 
+// SetDefaultClock sets the clock of job instances started without WithClock, RealClock by default.
+func (jd *JobDefinition[T]) SetDefaultClock(clock Clock) {
+	jd.clock = clock
+}
+
 // Start execution of the job definition.
 //
 //	this will create and return new instance of the job
@@ -65,6 +78,182 @@ func (jd *JobDefinition[T]) Start(ctx context.Context, input T, jobOptions ...Jo
 	return ji
 }
 
+// startUntyped is Start with input not strongly typed, used by JobManager.
+func (jd *JobDefinition[T]) startUntyped(ctx context.Context, input any, jobOptions ...JobOptionPreparer) (JobInstanceMeta, error) {
+	typedInput, ok := input.(T)
+	if !ok && input != nil {
+		return nil, ErrJobInputTypeMismatch.WithMessage(fmt.Sprintf(MsgJobInputTypeMismatch, jd.GetName(), typeName[T](), input))
+	}
+
+	return jd.StartIdempotent(ctx, typedInput, jobOptions...)
+}
+
+// Resume a job instance from the checkpoint saved in store by an earlier run (WithJobId, WithStateStore).
+//
+//	steps recorded as completed are not executed again, their saved output are fed to following steps.
+//	resuming is refused if step names or edges of the definition changed since the checkpoint was written.
+//	the resumed job instance is the next attempt of the checkpoint, like RetryFailed.
+func (jd *JobDefinition[T]) Resume(ctx context.Context, jobId string, store StateStore, jobOptions ...JobOptionPreparer) (*JobInstance[T], error) {
+	if !jd.Sealed() {
+		jd.Seal()
+	}
+
+	checkpoint, err := store.LoadJob(ctx, jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jd.checkShape(checkpoint.Shape); err != nil {
+		return nil, err
+	}
+
+	ji := newJobInstance(jd, *new(T), append(jobOptions, WithJobId(jobId), WithStateStore(store))...)
+	ji.continued = true
+	ji.attempt = checkpoint.attempt() + 1
+	if err := ji.jobOptions.Codec.Unmarshal(checkpoint.Input, &ji.input); err != nil {
+		return nil, fmt.Errorf("decode input of job %q: %w", jobId, err)
+	}
+
+	for stepName, stepCheckpoint := range checkpoint.Steps {
+		if stepCheckpoint.State != StepStateCompleted {
+			continue
+		}
+
+		// shape is checked, step must exists.
+		stepDef, _ := jd.GetStep(stepName)
+		output, err := stepDef.decodeOutput(stepCheckpoint.Output, ji.jobOptions.Codec)
+		if err != nil {
+			return nil, fmt.Errorf("decode output of step %q: %w", stepName, err)
+		}
+
+		ji.completedSteps[stepName] = &completedStep{
+			output:        output,
+			executionData: &StepExecutionData{StartTime: stepCheckpoint.StartTime, Duration: stepCheckpoint.Duration},
+		}
+	}
+
+	ji.start(ctx)
+
+	return ji, nil
+}
+
+// openCheckpoint returns a read-only job instance of a job finished in the checkpoint, no step is executed.
+//
+//	steps have state, output and error from the checkpoint, steps never executed are skipped.
+//	a job not finished in the checkpoint is refused with ErrJobRunningElsewhere, its outcome is not known yet.
+func (jd *JobDefinition[T]) openCheckpoint(checkpoint *JobCheckpoint, jobOptions ...JobOptionPreparer) (*JobInstance[T], error) {
+	if err := jd.checkShape(checkpoint.Shape); err != nil {
+		return nil, err
+	}
+	if !checkpointFinished(checkpoint, len(jd.steps)-1) {
+		return nil, ErrJobRunningElsewhere.WithMessage(fmt.Sprintf(MsgJobRunningElsewhere, checkpoint.JobId))
+	}
+
+	ji := newJobInstance(jd, *new(T), append(jobOptions, WithJobId(checkpoint.JobId))...)
+	ji.attempt = checkpoint.attempt()
+	if err := ji.jobOptions.Codec.Unmarshal(checkpoint.Input, &ji.input); err != nil {
+		return nil, fmt.Errorf("decode input of job %q: %w", checkpoint.JobId, err)
+	}
+	ji.cancel = func() {}
+
+	ji.rootStep = newStepInstance(jd.rootStep, ji)
+	ji.rootStep.task = asynctask.NewCompletedTask(ji.input)
+	ji.rootStep.state = StepStateCompleted
+	ji.steps[ji.rootStep.GetName()] = ji.rootStep
+	ji.stepsDag.AddNode(ji.rootStep)
+
+	for _, stepDef := range jd.stepsDag.TopologicalSort() {
+		if stepDef.GetName() == jd.GetName() {
+			continue
+		}
+
+		stepCheckpoint := checkpoint.Steps[stepDef.GetName()]
+		var output any
+		if stepCheckpoint != nil && stepCheckpoint.State == StepStateCompleted {
+			decoded, err := stepDef.decodeOutput(stepCheckpoint.Output, ji.jobOptions.Codec)
+			if err != nil {
+				return nil, fmt.Errorf("decode output of step %q: %w", stepDef.GetName(), err)
+			}
+			output = decoded
+		}
+
+		step := stepDef.createCheckpointStepInstance(ji, stepCheckpoint, output)
+		executionData := step.ExecutionData()
+		if !executionData.StartTime.IsZero() && (ji.executionData.StartTime.IsZero() || executionData.StartTime.Before(ji.executionData.StartTime)) {
+			ji.executionData.StartTime = executionData.StartTime
+		}
+		if endTime := executionData.StartTime.Add(executionData.Duration); endTime.After(ji.executionData.EndTime) {
+			ji.executionData.EndTime = endTime
+		}
+	}
+
+	for _, step := range ji.steps {
+		step.skipIfPending()
+	}
+	ji.state = ji.finalState(context.Background())
+	ji.executionData.Duration = ji.executionData.EndTime.Sub(ji.executionData.StartTime)
+
+	ji.events.close()
+	close(ji.done)
+
+	return ji, nil
+}
+
+// checkpointFinished returns true if no step is in progress, and a step failed or all stepCount steps completed.
+func checkpointFinished(checkpoint *JobCheckpoint, stepCount int) bool {
+	completed, failed := 0, 0
+	for _, step := range checkpoint.Steps {
+		switch step.State {
+		case StepStateCompleted:
+			completed++
+		case StepStateFailed:
+			failed++
+		case StepStateRunning, StepStateRetrying, StepStateWaiting:
+			return false
+		}
+	}
+
+	return failed > 0 || completed == stepCount
+}
+
+// shape returns step names and their preceding step names, used to detect definition changes.
+func (jd *JobDefinition[T]) shape() map[string][]string {
+	shape := make(map[string][]string, len(jd.steps))
+	for stepName, step := range jd.steps {
+		dependsOn := append([]string{}, step.DependsOn()...)
+		sort.Strings(dependsOn)
+		shape[stepName] = dependsOn
+	}
+
+	return shape
+}
+
+func (jd *JobDefinition[T]) checkShape(savedShape map[string][]string) error {
+	currentShape := jd.shape()
+
+	var diffs []string
+	for stepName, dependsOn := range currentShape {
+		savedDependsOn, ok := savedShape[stepName]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("step %q is added", stepName))
+		} else if strings.Join(savedDependsOn, ",") != strings.Join(dependsOn, ",") {
+			diffs = append(diffs, fmt.Sprintf("step %q depends on [%s] instead of [%s]", stepName, strings.Join(dependsOn, ", "), strings.Join(savedDependsOn, ", ")))
+		}
+	}
+	for stepName := range savedShape {
+		if _, ok := currentShape[stepName]; !ok {
+			diffs = append(diffs, fmt.Sprintf("step %q is removed", stepName))
+		}
+	}
+
+	if len(diffs) > 0 {
+		sort.Strings(diffs)
+		return ErrCheckpointShapeMismatch.WithMessage(fmt.Sprintf(MsgCheckpointShapeMismatch, jd.GetName(), strings.Join(diffs, "; ")))
+	}
+
+	return nil
+}
+
 func (jd *JobDefinition[T]) getRootStep() StepDefinitionMeta {
 	return jd.rootStep
 }
@@ -107,7 +296,7 @@ func (jd *JobDefinition[T]) addStep(step StepDefinitionMeta, precedingSteps ...S
 	return nil
 }
 
-// Visualize the job definition in graphviz dot format
-func (jd *JobDefinition[T]) Visualize() (string, error) {
-	return jd.stepsDag.ToDotGraph()
+// Visualize the job definition, in graphviz dot format by default
+func (jd *JobDefinition[T]) Visualize(options ...VisualizeOptionPreparer) (string, error) {
+	return visualize(jd.stepsDag, nil, newVisualizeOptions(options...))
 }
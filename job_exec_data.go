@@ -0,0 +1,23 @@
+package asyncjob
+
+import (
+	"time"
+)
+
+// JobExecutionData would measure the job execution time.
+type JobExecutionData struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+	// CapturedInput is the encoded job input, if WithCapture is used.
+	CapturedInput string
+	// Compensations executed after the job failed, in execution order.
+	Compensations []*CompensationReport
+}
+
+// copy returns a copy of the execution data, compensations are copied as they are appended while the job finishes.
+func (d *JobExecutionData) copy() *JobExecutionData {
+	copied := *d
+	copied.Compensations = append([]*CompensationReport(nil), d.Compensations...)
+	return &copied
+}
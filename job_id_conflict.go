@@ -0,0 +1,109 @@
+package asyncjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// JobIdConflictPolicy decides what happens when a job is started with an id that already exists,
+//
+//	in the JobManager it is started through, or in the StateStore of the job.
+type JobIdConflictPolicy string
+
+// JobIdConflictReject fails the start with ErrDuplicateJobId, this is the default.
+const JobIdConflictReject JobIdConflictPolicy = "reject"
+
+// JobIdConflictReturnExisting returns the existing job instance instead of starting a new one,
+//
+//	a job only found in StateStore is returned as a read-only instance built from the checkpoint, no step is executed,
+//	if it is not finished in the checkpoint, ErrJobRunningElsewhere is returned instead, see JobDefinition.StartIdempotent.
+const JobIdConflictReturnExisting JobIdConflictPolicy = "return-existing"
+
+// JobIdConflictRetryFailed is same as JobIdConflictReturnExisting, but an existing job instance finished without success is retried with RetryFailed,
+//
+//	including a read-only instance from StateStore, its completed steps are not executed again.
+const JobIdConflictRetryFailed JobIdConflictPolicy = "retry-failed"
+
+// WithJobIdConflictPolicy sets how starting a job with an existing id is handled, by JobManager.Start and JobDefinition.StartIdempotent.
+func WithJobIdConflictPolicy(policy JobIdConflictPolicy) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.IdConflictPolicy = policy
+		return options
+	}
+}
+
+// StartIdempotent is same as Start, but de-duplicated by job id against the StateStore (WithJobId, WithStateStore),
+//
+//	the job checkpoint is created with StateStore.CreateJob, so only one caller starts the job id, even across processes.
+//	if a checkpoint of the job id exists, it is handled by JobIdConflictPolicy, instead of starting the job again,
+//	a checkpoint not finished yet fails with ErrJobRunningElsewhere, unless the policy is JobIdConflictReject.
+func (jd *JobDefinition[T]) StartIdempotent(ctx context.Context, input T, jobOptions ...JobOptionPreparer) (*JobInstance[T], error) {
+	options := applyJobOptions(jobOptions)
+	if options.Id == "" || options.StateStore == nil {
+		return jd.Start(ctx, input, jobOptions...), nil
+	}
+
+	if !jd.Sealed() {
+		jd.Seal()
+	}
+
+	ji := newJobInstance(jd, input, jobOptions...)
+	err := ji.createCheckpoint(ctx)
+	if err == nil {
+		ji.start(ctx)
+		return ji, nil
+	}
+	if !errors.Is(err, ErrDuplicateJobId) {
+		return nil, err
+	}
+	if options.IdConflictPolicy == "" || options.IdConflictPolicy == JobIdConflictReject {
+		return nil, err
+	}
+
+	checkpoint, err := options.StateStore.LoadJob(ctx, options.Id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := jd.openCheckpoint(checkpoint, jobOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, err := resolveConflict(ctx, existing, options.IdConflictPolicy)
+	if err != nil {
+		return nil, err
+	}
+	return instance.(*JobInstance[T]), nil
+}
+
+// resolveConflict handles starting a job with id of an existing job instance, by policy.
+func resolveConflict(ctx context.Context, existing JobInstanceMeta, policy JobIdConflictPolicy) (JobInstanceMeta, error) {
+	switch policy {
+	case JobIdConflictReturnExisting:
+		return existing, nil
+	case JobIdConflictRetryFailed:
+		if retryable(existing) {
+			return existing.retryFailedUntyped(ctx)
+		}
+		return existing, nil
+	default:
+		return nil, ErrDuplicateJobId.WithMessage(fmt.Sprintf(MsgDuplicateJobId, existing.GetJobInstanceId()))
+	}
+}
+
+// retryable returns true if the job instance finished without success.
+func retryable(instance JobInstanceMeta) bool {
+	state := instance.GetState()
+	return state.IsTerminal() && state != JobStateSucceeded
+}
+
+// applyJobOptions returns jobOptions applied on empty options, without defaults.
+func applyJobOptions(jobOptions []JobOptionPreparer) *JobExecutionOptions {
+	options := &JobExecutionOptions{}
+	for _, decorator := range jobOptions {
+		options = decorator(options)
+	}
+
+	return options
+}
@@ -3,26 +3,74 @@ package asyncjob
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/Azure/go-asyncjob/graph"
 	"github.com/Azure/go-asynctask"
 	"github.com/google/uuid"
 )
 
+type JobState string
+
+const JobStatePending JobState = "pending"
+const JobStateRunning JobState = "running"
+const JobStateSucceeded JobState = "succeeded"
+const JobStateFailed JobState = "failed"
+const JobStateCancelled JobState = "cancelled"
+const JobStatePartiallySucceeded JobState = "partially-succeeded"
+
+// IsTerminal returns true if the job will not change state anymore.
+func (s JobState) IsTerminal() bool {
+	return s != JobStatePending && s != JobStateRunning
+}
+
 type JobInstanceMeta interface {
 	GetJobInstanceId() string
 	GetJobDefinition() JobDefinitionMeta
 	GetStepInstance(stepName string) (StepInstanceMeta, bool)
+	GetState() JobState
+	GetAttempt() int
+	ExecutionData() *JobExecutionData
 	Wait(context.Context) error
-	Visualize() (string, error)
+	Done() <-chan struct{}
+	Events() <-chan StepEvent
+	Cancel()
+	Signal(stepName string, value any) error
+	Visualize(...VisualizeOptionPreparer) (string, error)
+	Snapshot() *JobSnapshot
 
 	// not exposing for now
 	addStepInstance(step StepInstanceMeta, precedingSteps ...StepInstanceMeta)
+	getJobOptions() *JobExecutionOptions
+	getInput() any
+	retryFailedUntyped(context.Context) (JobInstanceMeta, error)
+	emitStepEvent(step StepInstanceMeta, from, to StepState, stepErr error)
+	emitStepProgress(step StepInstanceMeta, state StepState, progress StepProgress)
 }
 
 type JobExecutionOptions struct {
 	Id              string
 	RunSequentially bool
+
+	// StateStore to checkpoint job input and step results, nil to disable checkpointing.
+	StateStore StateStore
+	// Codec used to encode job input and step output for StateStore, default to JSONCodec.
+	Codec Codec
+	// CapturePolicy to record inputs and output of steps, nil to disable capturing.
+	CapturePolicy *CapturePolicy
+	// StepInterceptor wraps each execution of step func, nil to run step funcs as they are.
+	StepInterceptor StepInterceptor
+	// Clock of the job instance, default to clock of the job definition, or RealClock.
+	Clock Clock
+	// IdConflictPolicy decides what happens if the job id already exists, see JobIdConflictPolicy.
+	IdConflictPolicy JobIdConflictPolicy
+	// EventBufferSize and EventOverflowPolicy of Events() channel, see WithEventBuffer.
+	EventBufferSize     int
+	EventOverflowPolicy EventOverflowPolicy
+	// RateLimiterRegistry of rate limiters used by steps with WithRateLimit, default to DefaultRateLimiterRegistry.
+	RateLimiterRegistry *RateLimiterRegistry
 }
 
 type JobOptionPreparer func(*JobExecutionOptions) *JobExecutionOptions
@@ -41,6 +89,28 @@ func WithSequentialExecution() JobOptionPreparer {
 	}
 }
 
+// WithStateStore checkpoint job input and the output of each completed step into the store.
+func WithStateStore(store StateStore) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.StateStore = store
+		return options
+	}
+}
+
+// WithCodec override the codec used to encode job input and step output for StateStore.
+func WithCodec(codec Codec) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.Codec = codec
+		return options
+	}
+}
+
+// completedStep is the result of a step from an earlier run of the same job.
+type completedStep struct {
+	output        any
+	executionData *StepExecutionData
+}
+
 // JobInstance is the instance of a jobDefinition
 type JobInstance[T any] struct {
 	jobOptions *JobExecutionOptions
@@ -49,6 +119,25 @@ type JobInstance[T any] struct {
 	rootStep   *StepInstance[T]
 	steps      map[string]StepInstanceMeta
 	stepsDag   *graph.Graph[StepInstanceMeta]
+
+	// steps completed in an earlier run, they are not executed again.
+	completedSteps map[string]*completedStep
+
+	// attempt number of this job id, starting from 1, increased by RetryFailed and Resume, saved in the checkpoint.
+	attempt int
+	// continued is true if this run continues an earlier run of the job id (Resume, RetryFailed),
+	//   steps saved by the earlier run are kept in StateStore, otherwise they are removed.
+	continued bool
+
+	// mutex guards state and executionData, they are written by start and trackCompletion, and read by anyone.
+	mutex         sync.RWMutex
+	state         JobState
+	executionData *JobExecutionData
+	// closed once all steps finished, and job state is final.
+	done chan struct{}
+	// cancels context of the steps.
+	cancel context.CancelFunc
+	events *stepEvents
 }
 
 func newJobInstance[T any](jd *JobDefinition[T], input T, jobInstanceOptions ...JobOptionPreparer) *JobInstance[T] {
@@ -58,6 +147,13 @@ func newJobInstance[T any](jd *JobDefinition[T], input T, jobInstanceOptions ...
 		steps:      map[string]StepInstanceMeta{},
 		stepsDag:   graph.NewGraph(connectStepInstance),
 		jobOptions: &JobExecutionOptions{},
+
+		completedSteps: map[string]*completedStep{},
+
+		attempt:       1,
+		state:         JobStatePending,
+		executionData: &JobExecutionData{},
+		done:          make(chan struct{}),
 	}
 
 	for _, decorator := range jobInstanceOptions {
@@ -68,14 +164,47 @@ func newJobInstance[T any](jd *JobDefinition[T], input T, jobInstanceOptions ...
 		ji.jobOptions.Id = uuid.New().String()
 	}
 
+	if ji.jobOptions.Codec == nil {
+		ji.jobOptions.Codec = JSONCodec{}
+	}
+
+	if ji.jobOptions.Clock == nil {
+		ji.jobOptions.Clock = jd.clock
+	}
+
+	if ji.jobOptions.RateLimiterRegistry == nil {
+		ji.jobOptions.RateLimiterRegistry = DefaultRateLimiterRegistry
+	}
+
+	ji.events = newStepEvents(ji.jobOptions)
+
 	return ji
 }
 
 func (ji *JobInstance[T]) start(ctx context.Context) {
+	ctx, ji.cancel = context.WithCancel(ctx)
+	ji.events.cancelled = ctx.Done()
+	var capturedInput string
+	if ji.jobOptions.CapturePolicy != nil {
+		capturedInput = ji.jobOptions.CapturePolicy.capture(ji.Definition.GetName(), ji.input)
+	}
+	ji.mutex.Lock()
+	ji.executionData.StartTime = ji.jobOptions.Clock.Now()
+	ji.executionData.CapturedInput = capturedInput
+	ji.state = JobStateRunning
+	ji.mutex.Unlock()
+
 	// create root step instance
 	ji.rootStep = newStepInstance(ji.Definition.rootStep, ji)
-	ji.rootStep.task = asynctask.NewCompletedTask(ji.input)
-	ji.rootStep.state = StepStateCompleted
+	if err := ji.saveCheckpoint(ctx); err != nil {
+		// fail the root step, so no other step will run.
+		rootErr := newStepError(ErrStateStoreFailed, ji.rootStep, err)
+		ji.rootStep.task = asynctask.Start(ctx, func(context.Context) (T, error) { return *new(T), rootErr })
+		ji.rootStep.state = StepStateFailed
+	} else {
+		ji.rootStep.task = asynctask.NewCompletedTask(ji.input)
+		ji.rootStep.state = StepStateCompleted
+	}
 	ji.steps[ji.rootStep.GetName()] = ji.rootStep
 	ji.stepsDag.AddNode(ji.rootStep)
 
@@ -85,12 +214,124 @@ func (ji *JobInstance[T]) start(ctx context.Context) {
 		if stepDef.GetName() == ji.Definition.GetName() {
 			continue
 		}
+		if completed, ok := ji.completedSteps[stepDef.GetName()]; ok {
+			ji.steps[stepDef.GetName()] = stepDef.createCompletedStepInstance(ji, completed)
+			continue
+		}
 		ji.steps[stepDef.GetName()] = stepDef.createStepInstance(ctx, ji)
 
 		if ji.jobOptions.RunSequentially {
 			ji.steps[stepDef.GetName()].Waitable().Wait(ctx)
 		}
 	}
+
+	go ji.trackCompletion(ctx)
+}
+
+// saveCheckpoint records job input in StateStore if configured, steps of an earlier run are removed unless this run continues it.
+func (ji *JobInstance[T]) saveCheckpoint(ctx context.Context) error {
+	if ji.jobOptions.StateStore == nil {
+		return nil
+	}
+
+	checkpoint, err := ji.checkpoint()
+	if err != nil {
+		return err
+	}
+
+	if ji.continued {
+		return ji.jobOptions.StateStore.SaveJob(ctx, checkpoint)
+	}
+	return ji.jobOptions.StateStore.ResetJob(ctx, checkpoint)
+}
+
+// createCheckpoint records job input in StateStore, fails with ErrDuplicateJobId if the job id is saved already.
+func (ji *JobInstance[T]) createCheckpoint(ctx context.Context) error {
+	checkpoint, err := ji.checkpoint()
+	if err != nil {
+		return err
+	}
+
+	return ji.jobOptions.StateStore.CreateJob(ctx, checkpoint)
+}
+
+func (ji *JobInstance[T]) checkpoint() (*JobCheckpoint, error) {
+	input, err := ji.jobOptions.Codec.Marshal(ji.input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JobCheckpoint{
+		JobId:   ji.GetJobInstanceId(),
+		JobName: ji.Definition.GetName(),
+		Input:   input,
+		Shape:   ji.Definition.shape(),
+		Attempt: ji.attempt,
+	}, nil
+}
+
+// trackCompletion waits for every step to finish, then decide the final state of the job.
+func (ji *JobInstance[T]) trackCompletion(ctx context.Context) {
+	defer ji.cancel()
+	defer close(ji.done)
+	defer ji.events.close()
+
+	for _, step := range ji.steps {
+		// steps are started with ctx, they finish on their own once ctx is cancelled,
+		//   so we don't pass ctx here, job state is final only after all steps finished.
+		step.Waitable().Wait(context.Background())
+	}
+
+	// steps never started, as preceding steps failed or job is cancelled.
+	for _, step := range ji.steps {
+		step.skipIfPending()
+	}
+
+	state := ji.finalState(ctx)
+	if state != JobStateSucceeded {
+		ji.compensate(ctx)
+	}
+
+	ji.mutex.Lock()
+	defer ji.mutex.Unlock()
+	ji.executionData.EndTime = ji.jobOptions.Clock.Now()
+	ji.executionData.Duration = ji.executionData.EndTime.Sub(ji.executionData.StartTime)
+	ji.state = state
+}
+
+// finalState derives the job state from step states:
+//   - all steps completed: succeeded
+//   - any step failed after the job context is cancelled: cancelled
+//   - any step failed, while some other (non-root) steps completed: partially-succeeded
+//   - otherwise: failed
+func (ji *JobInstance[T]) finalState(ctx context.Context) JobState {
+	completed, failed := 0, 0
+	for _, step := range ji.steps {
+		if step == ji.rootStep {
+			continue
+		}
+
+		switch step.GetState() {
+		case StepStateCompleted:
+			completed++
+		case StepStateFailed:
+			failed++
+		}
+	}
+
+	if completed == len(ji.steps)-1 {
+		return JobStateSucceeded
+	}
+
+	if ctx.Err() != nil {
+		return JobStateCancelled
+	}
+
+	if failed > 0 && completed > 0 {
+		return JobStatePartiallySucceeded
+	}
+
+	return JobStateFailed
 }
 
 func (ji *JobInstance[T]) GetJobInstanceId() string {
@@ -101,12 +342,94 @@ func (ji *JobInstance[T]) GetJobDefinition() JobDefinitionMeta {
 	return ji.Definition
 }
 
+// RetryFailed starts a new attempt of this job instance, with same job id, input and options.
+//
+//	results of completed steps are reused, only failed and not executed steps (and their downstream) are executed.
+//	the job instance must be finished (Wait returned) before retry.
+func (ji *JobInstance[T]) RetryFailed(ctx context.Context) (*JobInstance[T], error) {
+	if state := ji.GetState(); !state.IsTerminal() {
+		return nil, ErrJobNotFinished.WithMessage(fmt.Sprintf(MsgJobNotFinished, ji.GetJobInstanceId(), state))
+	}
+
+	newAttempt := newJobInstance(ji.Definition, ji.input, func(*JobExecutionOptions) *JobExecutionOptions {
+		jobOptions := *ji.jobOptions
+		return &jobOptions
+	})
+	newAttempt.attempt = ji.attempt + 1
+	newAttempt.continued = true
+
+	// a step runs again if it did not complete, or any step it depends on runs again,
+	//   so a step running after a failed step (IgnoreOrderDependencyFailure) sees the new outcome.
+	rerun := map[string]bool{}
+	for _, step := range ji.stepsDag.TopologicalSort() {
+		if step == ji.rootStep {
+			continue
+		}
+
+		stepName := step.GetName()
+		for _, precedingName := range step.GetStepDefinition().DependsOn() {
+			if rerun[precedingName] {
+				rerun[stepName] = true
+			}
+		}
+		if rerun[stepName] {
+			continue
+		}
+
+		// a compensated step is undone, it needs to run again, a finally step always runs with outcomes of this attempt.
+		if step.GetStepDefinition().getStepType() == stepTypeFinally {
+			rerun[stepName] = true
+		} else if completed := step.getCompletedStep(); completed != nil && step.ExecutionData().Compensation == nil {
+			newAttempt.completedSteps[stepName] = completed
+		} else {
+			rerun[stepName] = true
+		}
+	}
+
+	newAttempt.start(ctx)
+
+	return newAttempt, nil
+}
+
+func (ji *JobInstance[T]) retryFailedUntyped(ctx context.Context) (JobInstanceMeta, error) {
+	return ji.RetryFailed(ctx)
+}
+
+// GetState returns the overall state of the job instance, it is final once Wait returns.
+func (ji *JobInstance[T]) GetState() JobState {
+	ji.mutex.RLock()
+	defer ji.mutex.RUnlock()
+
+	return ji.state
+}
+
+// GetAttempt returns the attempt number of this job id, starting from 1.
+func (ji *JobInstance[T]) GetAttempt() int {
+	return ji.attempt
+}
+
+// ExecutionData returns a copy of execution data of the job instance, it is safe to read while the job is running.
+func (ji *JobInstance[T]) ExecutionData() *JobExecutionData {
+	ji.mutex.RLock()
+	defer ji.mutex.RUnlock()
+
+	return ji.executionData.copy()
+}
+
 // GetStepInstance returns the stepInstance by name
 func (ji *JobInstance[T]) GetStepInstance(stepName string) (StepInstanceMeta, bool) {
 	stepMeta, ok := ji.steps[stepName]
 	return stepMeta, ok
 }
 
+func (ji *JobInstance[T]) getJobOptions() *JobExecutionOptions {
+	return ji.jobOptions
+}
+
+func (ji *JobInstance[T]) getInput() any {
+	return ji.input
+}
+
 func (ji *JobInstance[T]) addStepInstance(step StepInstanceMeta, precedingSteps ...StepInstanceMeta) {
 	ji.steps[step.GetName()] = step
 
@@ -124,12 +447,20 @@ func (ji *JobInstance[T]) Wait(ctx context.Context) error {
 	}
 
 	err := asynctask.WaitAll(ctx, &asynctask.WaitAllOptions{}, tasks...)
+	if err == nil || ctx.Err() == nil {
+		// all steps finished, wait for the job state to be final.
+		select {
+		case <-ji.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 
 	// return rootCaused error if possible
 	if err != nil {
 		jobErr := &JobError{}
 		if errors.As(err, &jobErr) {
-			return jobErr.RootCause()
+			return ji.withCompensations(jobErr.RootCause())
 		}
 
 		return err
@@ -138,7 +469,65 @@ func (ji *JobInstance[T]) Wait(ctx context.Context) error {
 	return nil
 }
 
-// Visualize the job instance in graphviz dot format
-func (jd *JobInstance[T]) Visualize() (string, error) {
-	return jd.stepsDag.ToDotGraph()
+// withCompensations returns a copy of the JobError with compensations of the finished job, other errors are returned as is.
+func (ji *JobInstance[T]) withCompensations(err error) error {
+	select {
+	case <-ji.done:
+	default:
+		// compensations are not finished yet.
+		return err
+	}
+
+	jobErr, ok := err.(*JobError)
+	compensations := ji.ExecutionData().Compensations
+	if !ok || len(compensations) == 0 {
+		return err
+	}
+
+	withCompensations := *jobErr
+	withCompensations.Compensations = compensations
+	return &withCompensations
+}
+
+// Done returns a channel closed once all steps finished, and job state is final.
+func (ji *JobInstance[T]) Done() <-chan struct{} {
+	return ji.done
+}
+
+// Cancel the context of steps, running steps are expected to return, pending steps won't start.
+//
+//	job state will be cancelled, unless all steps completed already.
+func (ji *JobInstance[T]) Cancel() {
+	ji.cancel()
+}
+
+// Visualize the job instance, in graphviz dot format by default
+func (ji *JobInstance[T]) Visualize(options ...VisualizeOptionPreparer) (string, error) {
+	visualizeOptions := newVisualizeOptions(options...)
+
+	stepsDag := ji.stepsDag
+	if visualizeOptions.HighlightCriticalPath && ji.GetState().IsTerminal() {
+		criticalPath := ji.criticalPath()
+		stepsDag = stepsDag.WithEdgeSpecFunc(func(stepFrom, stepTo StepInstanceMeta) *graph.DotEdgeSpec {
+			edgeSpec := connectStepInstance(stepFrom, stepTo)
+			if criticalPath.onPath(stepFrom.GetName(), stepTo.GetName()) {
+				edgeSpec.Color = "blue"
+				edgeSpec.Tooltip += "\nCritical path"
+			}
+			return edgeSpec
+		})
+	}
+
+	return visualize(stepsDag, ji.graphSpec(), visualizeOptions)
+}
+
+func (ji *JobInstance[T]) graphSpec() *graph.DotGraphSpec {
+	state := ji.GetState()
+	executionData := ji.ExecutionData()
+	label := fmt.Sprintf("%s (%s)\nAttempt: %d\nState: %s", ji.Definition.GetName(), ji.GetJobInstanceId(), ji.attempt, state)
+	if state.IsTerminal() {
+		label += fmt.Sprintf("\nStartAt: %s\nDuration: %s", executionData.StartTime.Format(time.RFC3339Nano), executionData.Duration)
+	}
+
+	return &graph.DotGraphSpec{Label: label}
 }
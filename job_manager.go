@@ -0,0 +1,386 @@
+package asyncjob
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobManager keeps track of job instances started through it, from job definitions registered by name.
+type JobManager struct {
+	options *JobManagerOptions
+
+	mutex       sync.Mutex
+	definitions map[string]JobDefinitionMeta
+	// instance is nil while the job is being started.
+	instances map[string]JobInstanceMeta
+	// closed once the job being started is stored in instances, or failed to start.
+	starting map[string]chan struct{}
+	// time job instances finished, by the clock of the JobManager, for retention.
+	finishedAt map[string]time.Time
+	shutdown   bool
+}
+
+type JobManagerOptions struct {
+	// Retention of finished job instances, they are evicted once finished longer than Retention, 0 to keep them forever.
+	Retention time.Duration
+	// Clock used to decide retention, RealClock by default.
+	Clock Clock
+}
+
+type JobManagerOptionPreparer func(*JobManagerOptions) *JobManagerOptions
+
+// WithRetention evicts finished job instances from the JobManager, once they finished longer than retention.
+func WithRetention(retention time.Duration) JobManagerOptionPreparer {
+	return func(options *JobManagerOptions) *JobManagerOptions {
+		options.Retention = retention
+		return options
+	}
+}
+
+// WithJobManagerClock override the clock used to decide retention.
+func WithJobManagerClock(clock Clock) JobManagerOptionPreparer {
+	return func(options *JobManagerOptions) *JobManagerOptions {
+		options.Clock = clock
+		return options
+	}
+}
+
+// JobFilter selects job instances in JobManager.List, empty fields match all.
+type JobFilter struct {
+	DefinitionName string
+	States         []JobState
+}
+
+// ShutdownMode decides what happens to running jobs, when JobManager shuts down.
+type ShutdownMode string
+
+// ShutdownDrain waits for running jobs to finish.
+const ShutdownDrain ShutdownMode = "drain"
+
+// ShutdownCancel cancels running jobs, then waits for them to finish.
+const ShutdownCancel ShutdownMode = "cancel"
+
+func NewJobManager(options ...JobManagerOptionPreparer) *JobManager {
+	m := &JobManager{
+		options:     &JobManagerOptions{},
+		definitions: map[string]JobDefinitionMeta{},
+		instances:   map[string]JobInstanceMeta{},
+		starting:    map[string]chan struct{}{},
+		finishedAt:  map[string]time.Time{},
+	}
+
+	for _, decorator := range options {
+		m.options = decorator(m.options)
+	}
+
+	if m.options.Clock == nil {
+		m.options.Clock = RealClock{}
+	}
+
+	return m
+}
+
+// Register a job definition by its name, so jobs can be started through the JobManager.
+func (m *JobManager) Register(definition JobDefinitionMeta) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.definitions[definition.GetName()]; ok {
+		return ErrJobDefinitionAlreadyRegistered.WithMessage(fmt.Sprintf(MsgJobDefinitionAlreadyRegistered, definition.GetName()))
+	}
+
+	m.definitions[definition.GetName()] = definition
+	return nil
+}
+
+// GetDefinition returns the job definition registered with name.
+func (m *JobManager) GetDefinition(name string) (JobDefinitionMeta, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	definition, ok := m.definitions[name]
+	return definition, ok
+}
+
+// ListDefinitions returns registered job definitions, sorted by name.
+func (m *JobManager) ListDefinitions() []JobDefinitionMeta {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	definitions := make([]JobDefinitionMeta, 0, len(m.definitions))
+	for _, definition := range m.definitions {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].GetName() < definitions[j].GetName() })
+
+	return definitions
+}
+
+// Start a job of the definition registered with definitionName, input must be of the input type of the definition.
+//
+//	job id is from WithJobId in jobOptions, or generated. if the id exists in the JobManager or StateStore of the job,
+//	it is handled by WithJobIdConflictPolicy, rejected by default.
+//	unless rejected, a job id still being started by another caller is waited for, until it is started or ctx is done.
+func (m *JobManager) Start(ctx context.Context, definitionName string, input any, jobOptions ...JobOptionPreparer) (JobInstanceMeta, error) {
+	m.mutex.Lock()
+	definition, ok := m.definitions[definitionName]
+	if !ok {
+		m.mutex.Unlock()
+		return nil, ErrJobDefinitionNotRegistered.WithMessage(fmt.Sprintf(MsgJobDefinitionNotRegistered, definitionName))
+	}
+	jobId, existing, err := m.reserveJobId(ctx, definitionName, jobOptions)
+	m.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return m.resolveConflict(ctx, existing, applyJobOptions(jobOptions).IdConflictPolicy)
+	}
+
+	instance, err := definition.startUntyped(ctx, input, append(jobOptions, WithJobId(jobId))...)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	defer m.startedLocked(jobId)
+	if err != nil {
+		delete(m.instances, jobId)
+		return nil, err
+	}
+	m.trackLocked(instance)
+	return instance, nil
+}
+
+// StartJob is same as JobManager.Start, with strongly typed job definition and input.
+func StartJob[T any](ctx context.Context, m *JobManager, jd *JobDefinition[T], input T, jobOptions ...JobOptionPreparer) (*JobInstance[T], error) {
+	instance, err := m.Start(ctx, jd.GetName(), input, jobOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	typedInstance, ok := instance.(*JobInstance[T])
+	if !ok {
+		// a different definition is registered with same name.
+		return nil, ErrJobInputTypeMismatch.WithMessage(fmt.Sprintf(MsgJobInputTypeMismatch, jd.GetName(), typeName[T](), input))
+	}
+	return typedInstance, nil
+}
+
+// reserveJobId returns job id from jobOptions or a new one, and reserves it, caller must hold the mutex.
+//
+//	existing job instance with same id is returned instead, if there is one.
+//	the mutex is released while waiting for a job id being started by another caller.
+func (m *JobManager) reserveJobId(ctx context.Context, definitionName string, jobOptions []JobOptionPreparer) (string, JobInstanceMeta, error) {
+	options := applyJobOptions(jobOptions)
+	if options.Id == "" {
+		options.Id = uuid.New().String()
+	}
+
+	for {
+		if m.shutdown {
+			return "", nil, ErrJobManagerShutdown.WithMessage(fmt.Sprintf(MsgJobManagerShutdown, definitionName))
+		}
+
+		m.evictLocked()
+		existing, ok := m.instances[options.Id]
+		if !ok {
+			m.startingLocked(options.Id)
+			return options.Id, nil, nil
+		}
+
+		if existing != nil {
+			if options.IdConflictPolicy == JobIdConflictRetryFailed && retryable(existing) {
+				// a new attempt is being started, so concurrent callers don't retry it again.
+				m.startingLocked(options.Id)
+			}
+			return options.Id, existing, nil
+		}
+
+		// still being started by another caller.
+		if options.IdConflictPolicy == "" || options.IdConflictPolicy == JobIdConflictReject {
+			return "", nil, ErrDuplicateJobId.WithMessage(fmt.Sprintf(MsgDuplicateJobId, options.Id))
+		}
+		started := m.starting[options.Id]
+		m.mutex.Unlock()
+		select {
+		case <-started:
+			m.mutex.Lock()
+		case <-ctx.Done():
+			m.mutex.Lock()
+			return "", nil, ctx.Err()
+		}
+	}
+}
+
+// startingLocked marks job id as being started, caller must hold the mutex, and call startedLocked once done.
+func (m *JobManager) startingLocked(jobId string) {
+	m.instances[jobId] = nil
+	m.starting[jobId] = make(chan struct{})
+}
+
+// startedLocked wakes up callers waiting for the job id to be started, caller must hold the mutex.
+func (m *JobManager) startedLocked(jobId string) {
+	if started, ok := m.starting[jobId]; ok {
+		close(started)
+		delete(m.starting, jobId)
+	}
+}
+
+// resolveConflict handles existing job instance by policy, a new attempt from RetryFailed replaces the existing one.
+func (m *JobManager) resolveConflict(ctx context.Context, existing JobInstanceMeta, policy JobIdConflictPolicy) (JobInstanceMeta, error) {
+	if policy != JobIdConflictRetryFailed || !retryable(existing) {
+		// existing job instance is returned as is, or rejected.
+		return resolveConflict(ctx, existing, policy)
+	}
+
+	// job id is marked as starting by reserveJobId.
+	instance, err := existing.retryFailedUntyped(ctx)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	defer m.startedLocked(existing.GetJobInstanceId())
+	if err != nil {
+		// new attempt failed to start, the existing one is kept.
+		m.instances[existing.GetJobInstanceId()] = existing
+		return nil, err
+	}
+	m.trackLocked(instance)
+	return instance, nil
+}
+
+// trackLocked stores the job instance, and records when it finished, caller must hold the mutex.
+func (m *JobManager) trackLocked(instance JobInstanceMeta) {
+	jobId := instance.GetJobInstanceId()
+	if m.instances[jobId] == instance {
+		return
+	}
+	m.instances[jobId] = instance
+	delete(m.finishedAt, jobId)
+
+	go func() {
+		<-instance.Done()
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		m.markFinishedLocked(jobId, instance)
+	}()
+}
+
+// markFinishedLocked records finish time of the job instance, if it is still tracked and not recorded yet, caller must hold the mutex.
+func (m *JobManager) markFinishedLocked(jobId string, instance JobInstanceMeta) {
+	if m.instances[jobId] != instance {
+		return
+	}
+	if _, ok := m.finishedAt[jobId]; !ok {
+		m.finishedAt[jobId] = m.options.Clock.Now()
+	}
+}
+
+// Get returns the job instance with jobId.
+func (m *JobManager) Get(jobId string) (JobInstanceMeta, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.evictLocked()
+	instance, ok := m.instances[jobId]
+	return instance, ok && instance != nil
+}
+
+// List returns job instances matching filter, ordered by start time.
+func (m *JobManager) List(filter JobFilter) []JobInstanceMeta {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.evictLocked()
+	var instances []JobInstanceMeta
+	for _, instance := range m.instances {
+		if instance != nil && filter.match(instance) {
+			instances = append(instances, instance)
+		}
+	}
+	startTimes := map[JobInstanceMeta]time.Time{}
+	for _, instance := range instances {
+		startTimes[instance] = instance.ExecutionData().StartTime
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return startTimes[instances[i]].Before(startTimes[instances[j]])
+	})
+
+	return instances
+}
+
+// Shutdown stops starting new jobs, then drains or cancels running jobs, and waits for them to finish or ctx is done.
+func (m *JobManager) Shutdown(ctx context.Context, mode ShutdownMode) error {
+	m.mutex.Lock()
+	m.shutdown = true
+	var instances []JobInstanceMeta
+	for _, instance := range m.instances {
+		if instance != nil {
+			instances = append(instances, instance)
+		}
+	}
+	m.mutex.Unlock()
+
+	for _, instance := range instances {
+		if mode == ShutdownCancel {
+			instance.Cancel()
+		}
+	}
+
+	for _, instance := range instances {
+		select {
+		case <-instance.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// evictLocked removes job instances finished longer than retention, caller must hold the mutex.
+func (m *JobManager) evictLocked() {
+	if m.options.Retention <= 0 {
+		return
+	}
+
+	for jobId, instance := range m.instances {
+		if instance == nil {
+			continue
+		}
+
+		// the watcher started by trackLocked may not have run yet.
+		select {
+		case <-instance.Done():
+			m.markFinishedLocked(jobId, instance)
+		default:
+			continue
+		}
+
+		if m.options.Clock.Since(m.finishedAt[jobId]) > m.options.Retention {
+			delete(m.instances, jobId)
+			delete(m.finishedAt, jobId)
+		}
+	}
+}
+
+func (f JobFilter) match(instance JobInstanceMeta) bool {
+	if f.DefinitionName != "" && instance.GetJobDefinition().GetName() != f.DefinitionName {
+		return false
+	}
+
+	if len(f.States) == 0 {
+		return true
+	}
+	for _, state := range f.States {
+		if instance.GetState() == state {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,385 @@
+package asyncjob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asyncjob/asyncjobtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobManager(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	manager := asyncjob.NewJobManager(asyncjob.WithRetention(time.Hour), asyncjob.WithJobManagerClock(clock))
+
+	jd := asyncjob.NewJobDefinition[string]("managedJob")
+	jd.SetDefaultClock(clock)
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Step", sleepStepFunc(0))
+	assert.NoError(t, err)
+
+	assert.NoError(t, manager.Register(jd))
+	assert.ErrorIs(t, manager.Register(jd), asyncjob.ErrJobDefinitionAlreadyRegistered)
+
+	_, err = manager.Start(context.Background(), "notRegistered", "input")
+	assert.ErrorIs(t, err, asyncjob.ErrJobDefinitionNotRegistered)
+	_, err = manager.Start(context.Background(), "managedJob", 1)
+	assert.ErrorIs(t, err, asyncjob.ErrJobInputTypeMismatch)
+
+	job1, err := asyncjob.StartJob(context.Background(), manager, jd, "input", asyncjob.WithJobId("job1"))
+	assert.NoError(t, err)
+	_, err = manager.Start(context.Background(), "managedJob", "input", asyncjob.WithJobId("job1"))
+	assert.ErrorIs(t, err, asyncjob.ErrDuplicateJobId)
+	assert.NoError(t, job1.Wait(context.Background()))
+
+	job2, err := manager.Start(context.Background(), "managedJob", "input")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, job2.GetJobInstanceId())
+	assert.NoError(t, job2.Wait(context.Background()))
+
+	found, ok := manager.Get("job1")
+	assert.True(t, ok)
+	assert.Equal(t, job1, found)
+	assert.Len(t, manager.List(asyncjob.JobFilter{States: []asyncjob.JobState{asyncjob.JobStateSucceeded}}), 2)
+	assert.Len(t, manager.List(asyncjob.JobFilter{States: []asyncjob.JobState{asyncjob.JobStateFailed}}), 0)
+	assert.Len(t, manager.List(asyncjob.JobFilter{DefinitionName: "otherJob"}), 0)
+
+	// finished jobs are evicted after retention.
+	clock.Advance(time.Hour + time.Second)
+	_, ok = manager.Get("job1")
+	assert.False(t, ok)
+	assert.Empty(t, manager.List(asyncjob.JobFilter{}))
+}
+
+func TestJobManagerRetentionClock(t *testing.T) {
+	t.Parallel()
+
+	// job steps use the real clock, retention is decided by the clock of the manager only.
+	clock := asyncjobtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	manager := asyncjob.NewJobManager(asyncjob.WithRetention(time.Hour), asyncjob.WithJobManagerClock(clock))
+	jd := asyncjob.NewJobDefinition[string]("retentionClockJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Step", sleepStepFunc(0))
+	assert.NoError(t, err)
+	assert.NoError(t, manager.Register(jd))
+
+	job, err := manager.Start(context.Background(), "retentionClockJob", "input", asyncjob.WithJobId("job1"))
+	assert.NoError(t, err)
+	assert.NoError(t, job.Wait(context.Background()))
+	_, ok := manager.Get("job1")
+	assert.True(t, ok)
+
+	clock.Advance(time.Hour - time.Second)
+	_, ok = manager.Get("job1")
+	assert.True(t, ok)
+
+	clock.Advance(2 * time.Second)
+	_, ok = manager.Get("job1")
+	assert.False(t, ok)
+}
+
+func TestJobManagerShutdown(t *testing.T) {
+	t.Parallel()
+
+	manager := asyncjob.NewJobManager()
+	jd := asyncjob.NewJobDefinition[string]("blockingJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Block", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, manager.Register(jd))
+
+	job, err := manager.Start(context.Background(), "blockingJob", "input")
+	assert.NoError(t, err)
+	assert.Len(t, manager.List(asyncjob.JobFilter{States: []asyncjob.JobState{asyncjob.JobStateRunning}}), 1)
+
+	// draining never finishes, as the step blocks until cancelled.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, manager.Shutdown(ctx, asyncjob.ShutdownDrain), context.DeadlineExceeded)
+
+	assert.NoError(t, manager.Shutdown(context.Background(), asyncjob.ShutdownCancel))
+	assert.Equal(t, asyncjob.JobStateCancelled, job.GetState())
+
+	_, err = manager.Start(context.Background(), "blockingJob", "input")
+	assert.ErrorIs(t, err, asyncjob.ErrJobManagerShutdown)
+}
+
+func TestJobManagerIdempotentStart(t *testing.T) {
+	t.Parallel()
+
+	var executions int32
+	jd := asyncjob.NewJobDefinition[string]("idempotentJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Count", func(ctx context.Context) (int32, error) {
+		count := atomic.AddInt32(&executions, 1)
+		if count == 2 {
+			return count, fmt.Errorf("second execution fails")
+		}
+		return count, nil
+	})
+	assert.NoError(t, err)
+
+	store := asyncjob.NewMemoryStateStore()
+	manager := asyncjob.NewJobManager()
+	assert.NoError(t, manager.Register(jd))
+
+	// redelivered start returns the existing instance.
+	job1, err := manager.Start(context.Background(), "idempotentJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+	assert.NoError(t, err)
+	assert.NoError(t, job1.Wait(context.Background()))
+	again, err := manager.Start(context.Background(), "idempotentJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+	assert.NoError(t, err)
+	assert.Equal(t, job1, again)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&executions))
+
+	// failed instance is retried as a new attempt.
+	job2, err := manager.Start(context.Background(), "idempotentJob", "input", asyncjob.WithJobId("job2"))
+	assert.NoError(t, err)
+	assert.Error(t, job2.Wait(context.Background()))
+	retried, err := manager.Start(context.Background(), "idempotentJob", "input", asyncjob.WithJobId("job2"), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictRetryFailed))
+	assert.NoError(t, err)
+	assert.NoError(t, retried.Wait(context.Background()))
+	assert.Equal(t, 2, retried.GetAttempt())
+	found, _ := manager.Get("job2")
+	assert.Equal(t, retried, found)
+
+	// a new manager (like after process restart) de-duplicates against the StateStore.
+	restarted := asyncjob.NewJobManager()
+	assert.NoError(t, restarted.Register(jd))
+	_, err = restarted.Start(context.Background(), "idempotentJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store))
+	assert.ErrorIs(t, err, asyncjob.ErrDuplicateJobId)
+
+	executionsBefore := atomic.LoadInt32(&executions)
+	resumed, err := restarted.Start(context.Background(), "idempotentJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+	assert.NoError(t, err)
+	assert.NoError(t, resumed.Wait(context.Background()))
+	assert.Equal(t, asyncjob.JobStateSucceeded, resumed.GetState())
+	assert.Equal(t, executionsBefore, atomic.LoadInt32(&executions))
+}
+
+func TestStartIdempotentConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var executions int32
+	jd := asyncjob.NewJobDefinition[string]("concurrentIdempotentJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Count", func(ctx context.Context) (int32, error) {
+		return atomic.AddInt32(&executions, 1), nil
+	})
+	assert.NoError(t, err)
+	jd.Seal()
+
+	fileStore, err := asyncjob.NewFileStateStore(t.TempDir())
+	assert.NoError(t, err)
+	for _, store := range []asyncjob.StateStore{asyncjob.NewMemoryStateStore(), fileStore} {
+		atomic.StoreInt32(&executions, 0)
+
+		var started, rejected int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				job, err := jd.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store))
+				if errors.Is(err, asyncjob.ErrDuplicateJobId) {
+					atomic.AddInt32(&rejected, 1)
+					return
+				}
+				assert.NoError(t, err)
+				assert.NoError(t, job.Wait(context.Background()))
+				atomic.AddInt32(&started, 1)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), started)
+		assert.Equal(t, int32(9), rejected)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&executions))
+	}
+}
+
+func TestStartIdempotentReturnExistingFromCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	var executions int32
+	jd := asyncjob.NewJobDefinition[string]("readOnlyJob")
+	countTsk, err := asyncjob.AddStepWithStaticFunc(jd, "Count", func(ctx context.Context) (int32, error) {
+		return atomic.AddInt32(&executions, 1), nil
+	})
+	assert.NoError(t, err)
+	failTsk, err := asyncjob.StepAfterWithStaticFunc(jd, "Fail", countTsk, func(ctx context.Context, count int32) (string, error) {
+		atomic.AddInt32(&executions, 1)
+		return "", fmt.Errorf("count is %d", count)
+	})
+	assert.NoError(t, err)
+	_, err = asyncjob.StepAfterWithStaticFunc(jd, "Notify", failTsk, func(ctx context.Context, message string) (string, error) {
+		atomic.AddInt32(&executions, 1)
+		return message, nil
+	})
+	assert.NoError(t, err)
+
+	store := asyncjob.NewMemoryStateStore()
+	job, err := jd.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store))
+	assert.NoError(t, err)
+	assert.Error(t, job.Wait(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executions))
+
+	// the existing job is rebuilt from the checkpoint, nothing is executed.
+	existing, err := jd.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+	assert.NoError(t, err)
+	assert.NotEqual(t, job, existing)
+	err = existing.Wait(context.Background())
+	assert.ErrorContains(t, err, "count is 1")
+	assert.Equal(t, asyncjob.JobStatePartiallySucceeded, existing.GetState())
+	countStep, _ := existing.GetStepInstance("Count")
+	assert.Equal(t, asyncjob.StepStateCompleted, countStep.GetState())
+	failStep, _ := existing.GetStepInstance("Fail")
+	assert.Equal(t, asyncjob.StepStateFailed, failStep.GetState())
+	// a step never executed is skipped, with error of the failed preceding step, instead of a zero output.
+	notifyStep, _ := existing.GetStepInstance("Notify")
+	assert.Equal(t, asyncjob.StepStateSkipped, notifyStep.GetState())
+	assert.ErrorContains(t, notifyStep.Waitable().Wait(context.Background()), "count is 1")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executions))
+	assert.Equal(t, 1, existing.GetAttempt())
+
+	// attempt number is saved in the checkpoint.
+	retried, err := jd.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictRetryFailed))
+	assert.NoError(t, err)
+	assert.Error(t, retried.Wait(context.Background()))
+	assert.Equal(t, 2, retried.GetAttempt())
+	existing, err = jd.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, existing.GetAttempt())
+	assert.Equal(t, 2, existing.Snapshot().Attempt)
+}
+
+func TestStartIdempotentRunningElsewhere(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	jd := asyncjob.NewJobDefinition[string]("runningJob")
+	blockTsk, err := asyncjob.AddStepWithStaticFunc(jd, "Block", func(ctx context.Context) (string, error) {
+		<-release
+		return "released", nil
+	})
+	assert.NoError(t, err)
+	jdWithResult, err := asyncjob.JobWithResult(jd, blockTsk)
+	assert.NoError(t, err)
+
+	store := asyncjob.NewMemoryStateStore()
+	job, err := jd.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store))
+	assert.NoError(t, err)
+
+	// the job is running (here, or in another process), its outcome is not known from the checkpoint.
+	for _, policy := range []asyncjob.JobIdConflictPolicy{asyncjob.JobIdConflictReturnExisting, asyncjob.JobIdConflictRetryFailed} {
+		_, err = jd.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store), asyncjob.WithJobIdConflictPolicy(policy))
+		assert.ErrorIs(t, err, asyncjob.ErrJobRunningElsewhere)
+	}
+
+	close(release)
+	assert.NoError(t, job.Wait(context.Background()))
+	existing, err := jdWithResult.StartIdempotent(context.Background(), "input", asyncjob.WithJobId("job1"), asyncjob.WithStateStore(store), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+	assert.NoError(t, err)
+	assert.NoError(t, existing.Wait(context.Background()))
+	assert.Equal(t, asyncjob.JobStateSucceeded, existing.GetState())
+	result, err := existing.Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "released", result)
+}
+
+func TestJobManagerConcurrentRetryFailed(t *testing.T) {
+	t.Parallel()
+
+	var executions int32
+	jd := asyncjob.NewJobDefinition[string]("concurrentRetryJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Count", func(ctx context.Context) (int32, error) {
+		if count := atomic.AddInt32(&executions, 1); count == 1 {
+			return count, fmt.Errorf("first execution fails")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return 0, nil
+	})
+	assert.NoError(t, err)
+
+	manager := asyncjob.NewJobManager()
+	assert.NoError(t, manager.Register(jd))
+	job, err := manager.Start(context.Background(), "concurrentRetryJob", "input", asyncjob.WithJobId("job1"))
+	assert.NoError(t, err)
+	assert.Error(t, job.Wait(context.Background()))
+
+	// only one new attempt is started, other callers see it is being started, or get the new attempt.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retried, err := manager.Start(context.Background(), "concurrentRetryJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictRetryFailed))
+			if errors.Is(err, asyncjob.ErrDuplicateJobId) {
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, retried.GetAttempt())
+		}()
+	}
+	wg.Wait()
+
+	found, ok := manager.Get("job1")
+	assert.True(t, ok)
+	assert.NoError(t, found.Wait(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executions))
+}
+
+func TestJobManagerStartWaitsForStartingJob(t *testing.T) {
+	t.Parallel()
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	jd := asyncjob.NewJobDefinition[string]("sequentialJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "Block", func(ctx context.Context) (string, error) {
+		close(running)
+		<-release
+		return "released", nil
+	})
+	assert.NoError(t, err)
+
+	manager := asyncjob.NewJobManager()
+	assert.NoError(t, manager.Register(jd))
+
+	// sequential execution blocks Start until the job finished.
+	first := make(chan asyncjob.JobInstanceMeta)
+	go func() {
+		instance, err := manager.Start(context.Background(), "sequentialJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithSequentialExecution())
+		assert.NoError(t, err)
+		first <- instance
+	}()
+	<-running
+
+	_, err = manager.Start(context.Background(), "sequentialJob", "input", asyncjob.WithJobId("job1"))
+	assert.ErrorIs(t, err, asyncjob.ErrDuplicateJobId)
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = manager.Start(cancelledCtx, "sequentialJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// redelivered start waits for the first one, instead of failing.
+	duplicate := make(chan asyncjob.JobInstanceMeta)
+	go func() {
+		instance, err := manager.Start(context.Background(), "sequentialJob", "input", asyncjob.WithJobId("job1"), asyncjob.WithJobIdConflictPolicy(asyncjob.JobIdConflictReturnExisting))
+		assert.NoError(t, err)
+		duplicate <- instance
+	}()
+	// give the duplicate time to start waiting.
+	time.Sleep(10 * time.Millisecond)
+
+	close(release)
+	instance := <-first
+	assert.Equal(t, instance, <-duplicate)
+	assert.NoError(t, instance.Wait(context.Background()))
+}
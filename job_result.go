@@ -29,12 +29,46 @@ type JobInstanceWithResult[Tin, Tout any] struct {
 func (jd *JobDefinitionWithResult[Tin, Tout]) Start(ctx context.Context, input Tin, jobOptions ...JobOptionPreparer) *JobInstanceWithResult[Tin, Tout] {
 	ji := jd.JobDefinition.Start(ctx, input, jobOptions...)
 
+	return withResult(ji, jd.resultStep)
+}
+
+// Resume is same as JobDefinition.Resume, the resumed job instance keeps Result().
+func (jd *JobDefinitionWithResult[Tin, Tout]) Resume(ctx context.Context, jobId string, store StateStore, jobOptions ...JobOptionPreparer) (*JobInstanceWithResult[Tin, Tout], error) {
+	ji, err := jd.JobDefinition.Resume(ctx, jobId, store, jobOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return withResult(ji, jd.resultStep), nil
+}
+
+// StartIdempotent is same as JobDefinition.StartIdempotent, the started or existing job instance keeps Result().
+func (jd *JobDefinitionWithResult[Tin, Tout]) StartIdempotent(ctx context.Context, input Tin, jobOptions ...JobOptionPreparer) (*JobInstanceWithResult[Tin, Tout], error) {
+	ji, err := jd.JobDefinition.StartIdempotent(ctx, input, jobOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return withResult(ji, jd.resultStep), nil
+}
+
+func withResult[Tin, Tout any](ji *JobInstance[Tin], resultStep *StepDefinition[Tout]) *JobInstanceWithResult[Tin, Tout] {
 	return &JobInstanceWithResult[Tin, Tout]{
 		JobInstance: ji,
-		resultStep:  getStrongTypedStepInstance(jd.resultStep, ji),
+		resultStep:  getStrongTypedStepInstance(resultStep, ji),
 	}
 }
 
+// RetryFailed is same as JobInstance.RetryFailed, the new attempt keeps Result().
+func (ji *JobInstanceWithResult[Tin, Tout]) RetryFailed(ctx context.Context) (*JobInstanceWithResult[Tin, Tout], error) {
+	newAttempt, err := ji.JobInstance.RetryFailed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return withResult(newAttempt, ji.resultStep.Definition), nil
+}
+
 // Result returns the result of the job from result step.
 //
 //	it doesn't wait for all steps to finish, you can use Result() after Wait() if desired.
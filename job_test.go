@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -41,6 +42,13 @@ func TestSimpleJob(t *testing.T) {
 	jobErr := jobInstance1.Wait(context.Background())
 	assert.NoError(t, jobErr)
 	renderGraph(t, jobInstance1)
+	assert.Equal(t, asyncjob.JobStateSucceeded, jobInstance1.GetState())
+	assert.False(t, jobInstance1.ExecutionData().StartTime.IsZero())
+	assert.Equal(t, jobInstance1.ExecutionData().EndTime.Sub(jobInstance1.ExecutionData().StartTime), jobInstance1.ExecutionData().Duration)
+	mermaidStr, err := jobInstance1.Visualize(asyncjob.WithVisualizeFormat(asyncjob.VisualizeFormatMermaid))
+	assert.NoError(t, err)
+	assert.Contains(t, mermaidStr, "State: succeeded")
+	assert.Contains(t, mermaidStr, "style n0 fill:green")
 
 	jobErr = jobInstance2.Wait(context.Background())
 	assert.NoError(t, jobErr)
@@ -91,6 +99,141 @@ func TestJobError(t *testing.T) {
 	errors.As(err, &jobErr)
 	assert.Equal(t, jobErr.Code, asyncjob.ErrStepFailed)
 	assert.Equal(t, "GetTableClient1", jobErr.StepInstance.GetName())
+	assert.Equal(t, asyncjob.JobStatePartiallySucceeded, jobInstance.GetState())
+
+	// step metadata, for routing failure to owner.
+	stepDef := jobErr.StepInstance.GetStepDefinition()
+	assert.Equal(t, "storage-team", stepDef.GetOwner())
+	assert.Equal(t, map[string]string{
+		"asyncjob.step.name":        "GetTableClient1",
+		"asyncjob.step.description": "get client of table1",
+		"asyncjob.step.owner":       "storage-team",
+		"asyncjob.step.tags":        "storage,table1",
+	}, stepDef.TelemetryAttributes())
+
+	snapshot := jobInstance.Snapshot()
+	assert.Equal(t, asyncjob.JobStatePartiallySucceeded, snapshot.State)
+	for _, step := range snapshot.Steps {
+		if step.Name == "GetTableClient1" {
+			assert.Equal(t, asyncjob.StepStateFailed, step.State)
+			assert.Equal(t, "storage-team", step.Owner)
+			assert.Equal(t, []string{"storage", "table1"}, step.Tags)
+			assert.Contains(t, step.Error, "table1 not exists")
+		}
+	}
+
+	dotGraph, err := jobInstance.Visualize()
+	assert.NoError(t, err)
+	assert.Contains(t, dotGraph, `Owner: storage-team\nTags: storage, table1`)
+}
+
+func TestJobRetryFailed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(context.Background(), testLoggingContextKey, t)
+	jobLib := NewSqlJobLib(&SqlSummaryJobParameters{
+		ServerName: "server1",
+		Table1:     "table1",
+		Query1:     "query1",
+		Table2:     "table2",
+		Query2:     "query2",
+		ErrorInjection: map[string]func() error{
+			"GetTableClient.server1.table1": func() error { return fmt.Errorf("table1 not reachable") },
+		},
+	})
+	jobInstance := SqlSummaryAsyncJobDefinition.Start(ctx, jobLib, asyncjob.WithJobId("retryFailedJob"))
+	assert.Error(t, jobInstance.Wait(context.Background()))
+	assert.Equal(t, 1, jobInstance.GetAttempt())
+
+	// transient outage is over
+	jobLib.Params.ErrorInjection = nil
+	retryInstance, err := jobInstance.RetryFailed(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, retryInstance.Wait(context.Background()))
+	assert.Equal(t, asyncjob.JobStateSucceeded, retryInstance.GetState())
+	assert.Equal(t, "retryFailedJob", retryInstance.GetJobInstanceId())
+	assert.Equal(t, 2, retryInstance.GetAttempt())
+	result, err := retryInstance.Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "table1", result.QueryResult1["tableName"])
+
+	// completed steps are reused, failed and downstream steps are executed again
+	for _, stepName := range []string{"GetConnection", "CheckAuth", "GetTableClient2", "QueryTable2"} {
+		originalStep, _ := jobInstance.GetStepInstance(stepName)
+		retriedStep, _ := retryInstance.GetStepInstance(stepName)
+		assert.Equal(t, originalStep.ExecutionData().StartTime, retriedStep.ExecutionData().StartTime, stepName)
+	}
+	for _, stepName := range []string{"GetTableClient1", "QueryTable1", "Summarize", "EmailNotification"} {
+		originalStep, _ := jobInstance.GetStepInstance(stepName)
+		retriedStep, _ := retryInstance.GetStepInstance(stepName)
+		assert.NotEqual(t, originalStep.ExecutionData().StartTime, retriedStep.ExecutionData().StartTime, stepName)
+		assert.Equal(t, asyncjob.StepStateCompleted, retriedStep.GetState(), stepName)
+	}
+
+	graphStr, err := retryInstance.Visualize()
+	assert.NoError(t, err)
+	assert.Contains(t, graphStr, "Attempt: 2")
+}
+
+func TestJobRetryFailedOrderDependency(t *testing.T) {
+	t.Parallel()
+
+	var flakyRuns, cleanupRuns, otherRuns int32
+	jd := asyncjob.NewJobDefinition[string]("retryOrderDependencyJob")
+	flakyTsk, err := asyncjob.AddStepWithStaticFunc(jd, "Flaky", func(ctx context.Context) (string, error) {
+		if atomic.AddInt32(&flakyRuns, 1) == 1 {
+			return "", fmt.Errorf("transient error")
+		}
+		return "flaky", nil
+	})
+	assert.NoError(t, err)
+	_, err = asyncjob.AddStepWithStaticFunc(jd, "Cleanup", func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&cleanupRuns, 1)
+		return "cleanup", nil
+	}, asyncjob.ExecuteAfter(flakyTsk), asyncjob.WithErrorPolicy(asyncjob.StepErrorPolicy{IgnoreOrderDependencyFailure: true}))
+	assert.NoError(t, err)
+	_, err = asyncjob.AddStepWithStaticFunc(jd, "Other", func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&otherRuns, 1)
+		return "other", nil
+	})
+	assert.NoError(t, err)
+
+	jobInstance := jd.Start(context.Background(), "input")
+	assert.Error(t, jobInstance.Wait(context.Background()))
+	cleanupStep, _ := jobInstance.GetStepInstance("Cleanup")
+	assert.Equal(t, asyncjob.StepStateCompleted, cleanupStep.GetState())
+
+	// Cleanup completed after Flaky failed, it runs again after Flaky, Other is reused.
+	retryInstance, err := jobInstance.RetryFailed(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, retryInstance.Wait(context.Background()))
+	assert.Equal(t, asyncjob.JobStateSucceeded, retryInstance.GetState())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&flakyRuns))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&cleanupRuns))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&otherRuns))
+}
+
+func TestJobCancel(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[string]("cancelJob")
+	_, err := asyncjob.AddStepWithStaticFunc(jd, "WaitForever", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobInstance := jd.Start(ctx, "input")
+	assert.Equal(t, asyncjob.JobStateRunning, jobInstance.GetState())
+	_, err = jobInstance.RetryFailed(ctx)
+	assert.ErrorIs(t, err, asyncjob.ErrJobNotFinished)
+	cancel()
+
+	err = jobInstance.Wait(context.Background())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, asyncjob.JobStateCancelled, jobInstance.GetState())
+	renderGraph(t, jobInstance)
 }
 
 func TestJobPanic(t *testing.T) {
@@ -159,6 +302,15 @@ func TestJobStepRetry(t *testing.T) {
 	assert.Equal(t, "QueryTable1", jobErr.StepInstance.GetName())
 	exeData := jobErr.StepInstance.ExecutionData()
 	assert.Equal(t, exeData.Retried.Count, 3)
+	assert.Equal(t, 4, len(exeData.Retried.Attempts))
+	assert.Equal(t, "query exeeded memory limit", exeData.Retried.Attempts[3].Error)
+
+	timeline, err := jobInstance.RenderTimeline()
+	assert.NoError(t, err)
+	assert.Contains(t, timeline, "<svg")
+	assert.Contains(t, timeline, "attempt 4:")
+	assert.Contains(t, timeline, `class="row critical"`)
+	assert.Contains(t, timeline, "not started")
 
 	// gain code coverage on retry policy in AddStep
 	jobInstance1 := jd.Start(ctx, NewSqlJobLib(&SqlSummaryJobParameters{
@@ -197,6 +349,117 @@ func TestJobStepRetry(t *testing.T) {
 	assert.Equal(t, "Summarize", jobErr.StepInstance.GetName())
 }
 
+func TestJobEvents(t *testing.T) {
+	t.Parallel()
+	jd, err := BuildJob(map[string]asyncjob.RetryPolicy{
+		"QueryTable1": newLinearRetryPolicy(time.Millisecond*3, 1),
+	})
+	assert.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), testLoggingContextKey, t)
+	jobInstance := jd.Start(ctx, NewSqlJobLib(&SqlSummaryJobParameters{
+		ServerName: "server1",
+		Table1:     "table1",
+		Query1:     "query1",
+		Table2:     "table2",
+		Query2:     "query2",
+		ErrorInjection: map[string]func() error{
+			"ExecuteQuery.server1.table1.query1": func() error { return fmt.Errorf("query exeeded memory limit") },
+		},
+	}))
+
+	transitions := map[string][]string{}
+	for event := range jobInstance.Events() {
+		assert.Equal(t, jobInstance.GetJobInstanceId(), event.JobId)
+		assert.False(t, event.Time.IsZero())
+		transitions[event.StepName] = append(transitions[event.StepName], string(event.From)+"->"+string(event.To))
+		if event.To == asyncjob.StepStateFailed {
+			assert.Equal(t, "query exeeded memory limit", event.Error)
+		}
+	}
+
+	// channel is closed once the job finished.
+	assert.Error(t, jobInstance.Wait(context.Background()))
+	assert.Equal(t, []string{"pending->running", "running->retrying", "retrying->running", "running->failed"}, transitions["QueryTable1"])
+	assert.Equal(t, []string{"pending->running", "running->completed"}, transitions["QueryTable2"])
+	assert.Equal(t, []string{"pending->skipped"}, transitions["Summarize"])
+	assert.NotContains(t, transitions, jd.GetName())
+
+	// events beyond buffer are dropped, instead of blocking steps.
+	jobInstance = jd.Start(ctx, NewSqlJobLib(&SqlSummaryJobParameters{
+		ServerName: "server2",
+		Table1:     "table3",
+		Query1:     "query3",
+		Table2:     "table4",
+		Query2:     "query4",
+	}), asyncjob.WithEventBuffer(2, asyncjob.EventOverflowDropNewest))
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+
+	count := 0
+	for range jobInstance.Events() {
+		count++
+	}
+	assert.Equal(t, 2, count)
+
+	// blocked steps give up sending once the job is cancelled, no one consumes events here.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	jobInstance = jd.Start(cancelCtx, NewSqlJobLib(&SqlSummaryJobParameters{
+		ServerName: "server3",
+		Table1:     "table5",
+		Query1:     "query5",
+		Table2:     "table6",
+		Query2:     "query6",
+	}), asyncjob.WithEventBuffer(1, asyncjob.EventOverflowBlock))
+	<-jobInstance.Events()
+	cancel()
+	select {
+	case <-jobInstance.Done():
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "job is blocked by events")
+	}
+	for range jobInstance.Events() {
+	}
+}
+
+func TestJobOrderDependencyFailure(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[string]("orderDependencyJob")
+	failTsk, err := asyncjob.AddStepWithStaticFunc(jd, "Fail", func(ctx context.Context) (string, error) { return "", fmt.Errorf("fail") })
+	assert.NoError(t, err)
+	cleanupTsk, err := asyncjob.AddStepWithStaticFunc(jd, "Cleanup", sleepStepFunc(0), asyncjob.ExecuteAfter(failTsk), asyncjob.WithErrorPolicy(asyncjob.StepErrorPolicy{IgnoreOrderDependencyFailure: true}))
+	assert.NoError(t, err)
+	_, err = asyncjob.AddStepWithStaticFunc(jd, "Notify", sleepStepFunc(0), asyncjob.ExecuteAfter(failTsk))
+	assert.NoError(t, err)
+	_, err = asyncjob.StepAfterWithStaticFunc(jd, "Report", failTsk, func(ctx context.Context, s string) (string, error) { return s, nil }, asyncjob.WithErrorPolicy(asyncjob.StepErrorPolicy{IgnoreOrderDependencyFailure: true}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, asyncjob.EdgeKindOrder, cleanupTsk.DependencyKind("Fail"))
+	assert.Equal(t, asyncjob.EdgeKind(""), cleanupTsk.DependencyKind("Notify"))
+	reportTsk, _ := jd.GetStep("Report")
+	assert.Equal(t, asyncjob.EdgeKindData, reportTsk.DependencyKind("Fail"))
+
+	jobInstance := jd.Start(context.Background(), "input")
+	assert.Error(t, jobInstance.Wait(context.Background()))
+
+	expectedStates := map[string]asyncjob.StepState{
+		"Fail":    asyncjob.StepStateFailed,
+		"Cleanup": asyncjob.StepStateCompleted,
+		"Notify":  asyncjob.StepStateSkipped,
+		"Report":  asyncjob.StepStateSkipped,
+	}
+	for stepName, expectedState := range expectedStates {
+		step, ok := jobInstance.GetStepInstance(stepName)
+		assert.True(t, ok)
+		assert.Equal(t, expectedState, step.GetState(), stepName)
+	}
+
+	dotGraph, err := jobInstance.Visualize()
+	assert.NoError(t, err)
+	assert.Contains(t, dotGraph, `"Fail" -> "Cleanup" [style="dashed"`)
+	assert.Contains(t, dotGraph, `"Fail" -> "Report" [style="bold"`)
+}
+
 func renderGraph(t *testing.T, jb GraphRender) {
 	graphStr, err := jb.Visualize()
 	assert.NoError(t, err)
@@ -205,5 +468,5 @@ func renderGraph(t *testing.T, jb GraphRender) {
 }
 
 type GraphRender interface {
-	Visualize() (string, error)
+	Visualize(...asyncjob.VisualizeOptionPreparer) (string, error)
 }
@@ -0,0 +1,98 @@
+package asyncjob
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// StepProgress is the latest progress reported by a running step.
+type StepProgress struct {
+	// Percent is between 0 and 100.
+	Percent    float64
+	Message    string
+	UpdateTime time.Time
+}
+
+// ProgressReporter publishes progress of the running step, get it with GetProgressReporter from the step context.
+type ProgressReporter interface {
+	// Report percent (clamped to 0-100) and a message of current progress, a NaN percent is ignored.
+	Report(percent float64, message string)
+}
+
+type progressReporterContextKey struct{}
+
+// GetProgressReporter returns ProgressReporter of the step running with ctx,
+//
+//	a no-op reporter is returned if ctx is not from a step, so step funcs can run outside of a job.
+func GetProgressReporter(ctx context.Context) ProgressReporter {
+	if reporter, ok := ctx.Value(progressReporterContextKey{}).(ProgressReporter); ok {
+		return reporter
+	}
+
+	return noopProgressReporter{}
+}
+
+// ReportProgress is shorthand of GetProgressReporter(ctx).Report(percent, message).
+func ReportProgress(ctx context.Context, percent float64, message string) {
+	GetProgressReporter(ctx).Report(percent, message)
+}
+
+type noopProgressReporter struct{}
+
+func (noopProgressReporter) Report(float64, string) {}
+
+// stepProgressReporter stores progress on the step instance, and emits it as a StepEvent.
+type stepProgressReporter[T any] struct {
+	stepInstance *StepInstance[T]
+}
+
+func withProgressReporter[T any](ctx context.Context, stepInstance *StepInstance[T]) context.Context {
+	return context.WithValue(ctx, progressReporterContextKey{}, &stepProgressReporter[T]{stepInstance: stepInstance})
+}
+
+func (r *stepProgressReporter[T]) Report(percent float64, message string) {
+	if math.IsNaN(percent) {
+		return
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+
+	si := r.stepInstance
+	progress := &StepProgress{Percent: percent, Message: message, UpdateTime: si.JobInstance.getJobOptions().Clock.Now()}
+
+	si.mutex.Lock()
+	si.progress = progress
+	state := si.state
+	si.mutex.Unlock()
+
+	si.JobInstance.emitStepProgress(si, state, *progress)
+}
+
+// Progress returns latest progress reported by the step, nil if it never reported.
+func (si *StepInstance[T]) Progress() *StepProgress {
+	si.mutex.RLock()
+	defer si.mutex.RUnlock()
+
+	if si.progress == nil {
+		return nil
+	}
+	progress := *si.progress
+	return &progress
+}
+
+func progressTooltip(progress *StepProgress) string {
+	if progress == nil {
+		return ""
+	}
+
+	tooltip := fmt.Sprintf("\nProgress: %.0f%%", progress.Percent)
+	if progress.Message != "" {
+		tooltip += " " + progress.Message
+	}
+	return tooltip
+}
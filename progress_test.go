@@ -0,0 +1,57 @@
+package asyncjob_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepProgress(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[int]("progressJob")
+	_, err := asyncjob.AddStep(jd, "Scan", func(rows int) asynctask.AsyncFunc[int] {
+		return func(ctx context.Context) (int, error) {
+			for scanned := 0; scanned <= rows; scanned += rows / 2 {
+				asyncjob.ReportProgress(ctx, float64(scanned)*100/float64(rows), "scanning")
+			}
+			asyncjob.ReportProgress(ctx, 150, "done")
+			asyncjob.ReportProgress(ctx, math.NaN(), "ignored")
+			return rows, nil
+		}
+	})
+	assert.NoError(t, err)
+
+	jobInstance := jd.Start(context.Background(), 10)
+	var progressEvents []*asyncjob.StepProgress
+	for event := range jobInstance.Events() {
+		if event.Progress != nil {
+			assert.Equal(t, asyncjob.StepStateRunning, event.To)
+			progressEvents = append(progressEvents, event.Progress)
+		}
+	}
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+
+	if assert.Len(t, progressEvents, 4) {
+		assert.Equal(t, float64(50), progressEvents[1].Percent)
+		assert.Equal(t, "scanning", progressEvents[1].Message)
+	}
+
+	step, ok := jobInstance.GetStepInstance("Scan")
+	assert.True(t, ok)
+	progress := step.Progress()
+	if assert.NotNil(t, progress) {
+		// clamped to 100
+		assert.Equal(t, float64(100), progress.Percent)
+		assert.Equal(t, "done", progress.Message)
+	}
+	assert.Contains(t, step.DotSpec().Tooltip, "Progress: 100% done")
+	assert.Equal(t, progress, jobInstance.Snapshot().Steps[0].Progress)
+
+	// reporting outside of a step is a no-op.
+	asyncjob.ReportProgress(context.Background(), 10, "ignored")
+}
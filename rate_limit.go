@@ -0,0 +1,155 @@
+package asyncjob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiterRegistry holds token bucket rate limiters by name, shared by steps of all job instances using the registry.
+type RateLimiterRegistry struct {
+	clock    Clock
+	mutex    sync.Mutex
+	limiters map[string]*tokenBucket
+}
+
+// DefaultRateLimiterRegistry is used by job instances without WithRateLimiterRegistry.
+var DefaultRateLimiterRegistry = NewRateLimiterRegistry(nil)
+
+// NewRateLimiterRegistry creates an empty registry, clock is used to refill tokens and wait for them, RealClock if nil.
+func NewRateLimiterRegistry(clock Clock) *RateLimiterRegistry {
+	if clock == nil {
+		clock = RealClock{}
+	}
+
+	return &RateLimiterRegistry{clock: clock, limiters: map[string]*tokenBucket{}}
+}
+
+// Register a token bucket rate limiter, allowing ratePerSecond requests per second on average, and burst requests at once.
+func (r *RateLimiterRegistry) Register(name string, ratePerSecond float64, burst int) error {
+	if ratePerSecond <= 0 || burst < 1 {
+		return ErrInvalidRateLimit.WithMessage(fmt.Sprintf(MsgInvalidRateLimit, name, ratePerSecond, burst))
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.limiters[name]; ok {
+		return ErrRateLimiterAlreadyRegistered.WithMessage(fmt.Sprintf(MsgRateLimiterAlreadyRegistered, name))
+	}
+
+	r.limiters[name] = &tokenBucket{rate: ratePerSecond, burst: float64(burst), tokens: float64(burst), last: r.clock.Now()}
+	return nil
+}
+
+// Wait takes a token from the rate limiter, waiting for it if there is none, returns how long it waited.
+func (r *RateLimiterRegistry) Wait(ctx context.Context, name string) (time.Duration, error) {
+	r.mutex.Lock()
+	limiter, ok := r.limiters[name]
+	r.mutex.Unlock()
+	if !ok {
+		return 0, ErrRateLimiterNotFound.WithMessage(fmt.Sprintf(MsgRateLimiterNotFound, name))
+	}
+
+	start := r.clock.Now()
+	wait := limiter.reserve(start)
+	if wait <= 0 {
+		return 0, nil
+	}
+
+	select {
+	case <-r.clock.After(wait):
+		return wait, nil
+	case <-ctx.Done():
+		limiter.cancel()
+		return r.clock.Since(start), ctx.Err()
+	}
+}
+
+// tokenBucket refills rate tokens per second up to burst, tokens go negative for reservations waiting.
+type tokenBucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token, returns how long to wait before it is available.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token.
+func (b *tokenBucket) cancel() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens++
+}
+
+// WithRateLimit takes a token from the rate limiter named limiterName before each attempt of the step,
+//
+//	the limiter is from the RateLimiterRegistry of the job instance, see WithRateLimiterRegistry.
+//	time waited is recorded in StepExecutionData.RateLimitWait and AttemptReport.RateLimitWait, it is included in Duration,
+//	and drawn as a throttled segment ahead of the attempt in RenderTimeline.
+func WithRateLimit(limiterName string) ExecutionOptionPreparer {
+	return func(options *StepExecutionOptions) *StepExecutionOptions {
+		options.RateLimiter = limiterName
+		return options
+	}
+}
+
+// WithRateLimiterRegistry override the registry of rate limiters used by steps with WithRateLimit, default to DefaultRateLimiterRegistry.
+func WithRateLimiterRegistry(registry *RateLimiterRegistry) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.RateLimiterRegistry = registry
+		return options
+	}
+}
+
+// rateLimitStepFunc waits for the rate limiter of the step before running stepFunc.
+func rateLimitStepFunc[T any](stepInstance *StepInstance[T], registry *RateLimiterRegistry, stepFunc func(ctx context.Context) (T, error)) func(ctx context.Context) (T, error) {
+	limiterName := stepInstance.Definition.executionOptions.RateLimiter
+	return func(ctx context.Context) (T, error) {
+		waited, err := registry.Wait(ctx, limiterName)
+		stepInstance.updateExecutionData(func(executionData *StepExecutionData) { executionData.RateLimitWait += waited })
+		if err != nil {
+			return *new(T), err
+		}
+
+		return stepFunc(ctx)
+	}
+}
+
+// rateLimitWait returns total time the step waited for its rate limiter so far.
+func (si *StepInstance[T]) rateLimitWait() time.Duration {
+	si.mutex.RLock()
+	defer si.mutex.RUnlock()
+
+	return si.executionData.RateLimitWait
+}
+
+func rateLimitTooltip(executionData *StepExecutionData) string {
+	if executionData.RateLimitWait <= 0 {
+		return ""
+	}
+
+	return "\nRateLimitWait: " + executionData.RateLimitWait.String()
+}
@@ -0,0 +1,108 @@
+package asyncjob_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asyncjob/asyncjobtest"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	registry := asyncjob.NewRateLimiterRegistry(clock)
+	assert.NoError(t, registry.Register("api", 1, 1))
+	assert.True(t, errors.Is(registry.Register("api", 1, 1), asyncjob.ErrRateLimiterAlreadyRegistered))
+	assert.True(t, errors.Is(registry.Register("invalid", 0, 1), asyncjob.ErrInvalidRateLimit))
+
+	jd := asyncjob.NewJobDefinition[string]("rateLimitedJob")
+	for _, stepName := range []string{"Call1", "Call2"} {
+		_, err := asyncjob.AddStep(jd, stepName, func(string) asynctask.AsyncFunc[bool] {
+			return func(ctx context.Context) (bool, error) { return true, nil }
+		}, asyncjob.WithRateLimit("api"))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "api", jd.Describe().Steps[0].RateLimiter)
+
+	// limiter is shared by both job instances, 4 calls at 1 per second.
+	jobInstance1 := jd.Start(context.Background(), "job1", asyncjob.WithRateLimiterRegistry(registry))
+	jobInstance2 := jd.Start(context.Background(), "job2", asyncjob.WithRateLimiterRegistry(registry))
+	clock.BlockUntil(3)
+	clock.Advance(3 * time.Second)
+	assert.NoError(t, jobInstance1.Wait(context.Background()))
+	assert.NoError(t, jobInstance2.Wait(context.Background()))
+
+	var waits []time.Duration
+	for _, jobInstance := range []*asyncjob.JobInstance[string]{jobInstance1, jobInstance2} {
+		for _, stepName := range []string{"Call1", "Call2"} {
+			step, _ := jobInstance.GetStepInstance(stepName)
+			waits = append(waits, step.ExecutionData().RateLimitWait)
+			if step.ExecutionData().RateLimitWait > 0 {
+				assert.Contains(t, step.DotSpec().Tooltip, "RateLimitWait: ")
+			}
+		}
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	assert.Equal(t, []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}, waits)
+
+	timeline, err := jobInstance2.RenderTimeline()
+	assert.NoError(t, err)
+	assert.Contains(t, timeline, `class="throttled"`)
+	assert.Contains(t, timeline, "rate limited: ")
+
+	// limiter not registered in default registry.
+	err = jd.Start(context.Background(), "job3").Wait(context.Background())
+	assert.True(t, errors.Is(err, asyncjob.ErrRateLimiterNotFound))
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	registry := asyncjob.NewRateLimiterRegistry(clock)
+	assert.NoError(t, registry.Register("api", 1, 1))
+
+	jd := asyncjob.NewJobDefinition[string]("rateLimitedRetryJob")
+	calls := 0
+	_, err := asyncjob.AddStep(jd, "Call", func(string) asynctask.AsyncFunc[bool] {
+		return func(ctx context.Context) (bool, error) {
+			calls++
+			if calls == 1 {
+				return false, errors.New("transient")
+			}
+			return true, nil
+		}
+	}, asyncjob.WithRateLimit("api"), asyncjob.WithRetry(newLinearRetryPolicy(0, 1)))
+	assert.NoError(t, err)
+
+	// first attempt takes the only token, second attempt waits for the next one.
+	jobInstance := jd.Start(context.Background(), "job1", asyncjob.WithRateLimiterRegistry(registry), asyncjob.WithClock(clock))
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	assert.NoError(t, jobInstance.Wait(context.Background()))
+
+	step, _ := jobInstance.GetStepInstance("Call")
+	executionData := step.ExecutionData()
+	assert.Equal(t, time.Second, executionData.RateLimitWait)
+	assert.Len(t, executionData.Retried.Attempts, 2)
+	assert.Equal(t, time.Duration(0), executionData.Retried.Attempts[0].RateLimitWait)
+	assert.Equal(t, time.Second, executionData.Retried.Attempts[1].RateLimitWait)
+	assert.Equal(t, time.Second, executionData.Retried.Attempts[1].Duration)
+
+	report, err := jobInstance.AnalyzeCriticalPath()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, report.Steps["Call"].RateLimitWait)
+	assert.Equal(t, time.Second, report.Steps["Call"].Duration)
+
+	timeline, err := jobInstance.RenderTimeline()
+	assert.NoError(t, err)
+	assert.Contains(t, timeline, "rate limited: 1s")
+	assert.Contains(t, timeline, "attempt 2: 0s")
+}
@@ -1,6 +1,7 @@
 package asyncjob
 
 import (
+	"sync"
 	"time"
 )
 
@@ -8,20 +9,31 @@ import (
 type retryer[T any] struct {
 	retryPolicy RetryPolicy
 	retryReport *RetryReport
-	function    func() (T, error)
+	// mutex guards retryReport, it is read while the step is running.
+	mutex sync.Locker
+	clock Clock
+	// setState is called with retrying before waiting for next attempt, and running after.
+	setState func(StepState)
+	// rateLimitWait returns total time the step waited for the rate limiter so far.
+	rateLimitWait func() time.Duration
+	function      func() (T, error)
 }
 
-func newRetryer[T any](policy RetryPolicy, report *RetryReport, toRetry func() (T, error)) *retryer[T] {
-	return &retryer[T]{retryPolicy: policy, retryReport: report, function: toRetry}
+func newRetryer[T any](policy RetryPolicy, report *RetryReport, mutex sync.Locker, clock Clock, setState func(StepState), rateLimitWait func() time.Duration, toRetry func() (T, error)) *retryer[T] {
+	return &retryer[T]{retryPolicy: policy, retryReport: report, mutex: mutex, clock: clock, setState: setState, rateLimitWait: rateLimitWait, function: toRetry}
 }
 
 func (r retryer[T]) Run() (T, error) {
-	t, err := r.function()
+	t, err := r.runAttempt()
 	for err != nil {
 		if shouldRetry, duration := r.retryPolicy.ShouldRetry(err); shouldRetry {
+			r.mutex.Lock()
 			r.retryReport.Count++
-			time.Sleep(duration)
-			t, err = r.function()
+			r.mutex.Unlock()
+			r.setState(StepStateRetrying)
+			<-r.clock.After(duration)
+			r.setState(StepStateRunning)
+			t, err = r.runAttempt()
 		} else {
 			break
 		}
@@ -29,3 +41,19 @@ func (r retryer[T]) Run() (T, error) {
 
 	return t, err
 }
+
+func (r retryer[T]) runAttempt() (T, error) {
+	attempt := &AttemptReport{StartTime: r.clock.Now()}
+	waitedBefore := r.rateLimitWait()
+	t, err := r.function()
+	attempt.Duration = r.clock.Since(attempt.StartTime)
+	attempt.RateLimitWait = r.rateLimitWait() - waitedBefore
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	r.mutex.Lock()
+	r.retryReport.Attempts = append(r.retryReport.Attempts, attempt)
+	r.mutex.Unlock()
+	return t, err
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when jobs are started.
+type Schedule interface {
+	// Next returns the first time to start a job strictly after after, zero time if there is no more.
+	Next(after time.Time) time.Time
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every starts a job at fixed interval, the first one is interval after the scheduler started.
+func Every(interval time.Duration) (Schedule, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+
+	return &intervalSchedule{interval: interval}, nil
+}
+
+func (s *intervalSchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// cronSchedule matches times by minute, hour, day of month, month and day of week.
+type cronSchedule struct {
+	minutes     [60]bool
+	hours       [24]bool
+	daysOfMonth [32]bool
+	months      [13]bool
+	daysOfWeek  [7]bool
+	// day of month and day of week matches either when both are restricted, like cron does.
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSearchLimit bounds the search of next time, for expressions never matching (e.g. Feb 30).
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// Cron parses a standard 5 fields cron expression: minute hour day-of-month month day-of-week,
+//
+//	each field is *, a number, a range (1-5), a list (1,3,5) or with a step (*/15, 0-30/10).
+//	macros @yearly, @monthly, @weekly, @daily and @hourly are supported, times are in the location of the scheduler clock.
+func Cron(expression string) (Schedule, error) {
+	if macro, ok := cronMacros[strings.TrimSpace(expression)]; ok {
+		expression = macro
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expression, len(fields))
+	}
+
+	s := &cronSchedule{
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}
+	parsers := []struct {
+		name     string
+		min, max int
+		set      func(int)
+	}{
+		{"minute", 0, 59, func(v int) { s.minutes[v] = true }},
+		{"hour", 0, 23, func(v int) { s.hours[v] = true }},
+		{"day of month", 1, 31, func(v int) { s.daysOfMonth[v] = true }},
+		{"month", 1, 12, func(v int) { s.months[v] = true }},
+		// 7 is also sunday.
+		{"day of week", 0, 7, func(v int) { s.daysOfWeek[v%7] = true }},
+	}
+	for i, parser := range parsers {
+		if err := parseCronField(fields[i], parser.min, parser.max, parser.set); err != nil {
+			return nil, fmt.Errorf("cron expression %q has invalid %s: %w", expression, parser.name, err)
+		}
+	}
+
+	return s, nil
+}
+
+func parseCronField(field string, min, max int, set func(int)) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		low, high := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			low, err1 = strconv.Atoi(bounds[0])
+			high, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return fmt.Errorf("invalid value %q", rangePart)
+			}
+			low, high = value, value
+			if strings.Contains(part, "/") {
+				// 5/15 means from 5 to max, every 15.
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			set(v)
+		}
+	}
+
+	return nil
+}
+
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchLimit)
+	for t.Before(limit) {
+		switch {
+		case !s.months[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dayOfMonth, dayOfWeek := s.daysOfMonth[t.Day()], s.daysOfWeek[t.Weekday()]
+	if !s.anyDayOfMonth && !s.anyDayOfWeek {
+		return dayOfMonth || dayOfWeek
+	}
+
+	return dayOfMonth && dayOfWeek
+}
@@ -0,0 +1,55 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron(t *testing.T) {
+	t.Parallel()
+
+	// friday
+	friday := time.Date(2024, 3, 1, 17, 50, 0, 0, time.UTC)
+	tests := []struct {
+		expression string
+		after      time.Time
+		next       time.Time
+	}{
+		{"*/15 9-17 * * 1-5", friday, time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * 1-5", friday.Add(-10 * time.Minute), time.Date(2024, 3, 1, 17, 45, 0, 0, time.UTC)},
+		{"0 0 * * *", friday, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", friday, time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)},
+		{"30 2 29 2 *", friday, time.Date(2028, 2, 29, 2, 30, 0, 0, time.UTC)},
+		// either day of month or day of week matches, when both are set.
+		{"0 12 13 * 5", friday, time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"5,10 * * * 7", friday, time.Date(2024, 3, 3, 0, 5, 0, 0, time.UTC)},
+		{"0 0 31 2 *", friday, time.Time{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := scheduler.Cron(test.expression)
+		if assert.NoError(t, err, test.expression) {
+			assert.Equal(t, test.next, schedule.Next(test.after), test.expression)
+		}
+	}
+
+	for _, invalid := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := scheduler.Cron(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	t.Parallel()
+
+	schedule, err := scheduler.Every(time.Minute)
+	assert.NoError(t, err)
+	now := time.Date(2024, 3, 1, 17, 50, 30, 0, time.UTC)
+	assert.Equal(t, now.Add(time.Minute), schedule.Next(now))
+
+	_, err = scheduler.Every(0)
+	assert.Error(t, err)
+}
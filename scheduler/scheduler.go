@@ -0,0 +1,369 @@
+// Package scheduler starts job instances of an asyncjob.JobDefinition on a schedule, a cron expression or a fixed interval.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+)
+
+// InputFactory creates job input for the run scheduled at scheduledTime.
+type InputFactory[T any] func(ctx context.Context, scheduledTime time.Time) (T, error)
+
+// OverlapPolicy decides what happens when a run is due, while job instance of an earlier run is still running.
+type OverlapPolicy string
+
+// OverlapSkip skips the run, this is the default.
+const OverlapSkip OverlapPolicy = "skip"
+
+// OverlapQueue starts the run once earlier runs finished, one at a time.
+const OverlapQueue OverlapPolicy = "queue"
+
+// OverlapAllow starts the run concurrently with earlier runs.
+const OverlapAllow OverlapPolicy = "allow"
+
+// MissedRunPolicy decides what happens to runs missed, as the scheduler didn't get to fire on time (process paused, clock jumped).
+type MissedRunPolicy string
+
+// MissedRunSkip skips missed runs, only the latest due run is started, this is the default.
+const MissedRunSkip MissedRunPolicy = "skip"
+
+// MissedRunCatchUp starts each missed run, in order of their scheduled time.
+const MissedRunCatchUp MissedRunPolicy = "catch-up"
+
+// RunStatus is the outcome of a scheduled run.
+type RunStatus string
+
+const RunStatusStarted RunStatus = "started"
+const RunStatusQueued RunStatus = "queued"
+const RunStatusSkippedOverlap RunStatus = "skipped-overlap"
+const RunStatusSkippedMissed RunStatus = "skipped-missed"
+const RunStatusFailedToStart RunStatus = "failed-to-start"
+
+// RunStatusDropped is a queued run never started, as the scheduler is stopped.
+const RunStatusDropped RunStatus = "dropped"
+
+// Run is a scheduled run, with the job instance if it is started.
+type Run[T any] struct {
+	ScheduledTime time.Time
+	JobId         string
+	Status        RunStatus
+	Instance      *asyncjob.JobInstance[T]
+	// Error from InputFactory, if it failed to start.
+	Error error
+}
+
+type Options struct {
+	OverlapPolicy   OverlapPolicy
+	MissedRunPolicy MissedRunPolicy
+	// Clock to wait for scheduled time, RealClock by default.
+	Clock asyncjob.Clock
+	// JobIdFunc generates job id of a run, default to "<job name>-<scheduled time in UTC>".
+	JobIdFunc func(jobName string, scheduledTime time.Time) string
+	// JobOptions applied to each job instance started.
+	JobOptions []asyncjob.JobOptionPreparer
+	// History is how many recent runs are kept for Runs(), default to 100.
+	History int
+}
+
+type OptionPreparer func(*Options) *Options
+
+// WithOverlapPolicy decides what happens when a run is due, while an earlier run is still running.
+func WithOverlapPolicy(policy OverlapPolicy) OptionPreparer {
+	return func(options *Options) *Options {
+		options.OverlapPolicy = policy
+		return options
+	}
+}
+
+// WithMissedRunPolicy decides what happens to runs missed, as the scheduler didn't get to fire on time.
+func WithMissedRunPolicy(policy MissedRunPolicy) OptionPreparer {
+	return func(options *Options) *Options {
+		options.MissedRunPolicy = policy
+		return options
+	}
+}
+
+// WithClock override the clock used to wait for scheduled time.
+func WithClock(clock asyncjob.Clock) OptionPreparer {
+	return func(options *Options) *Options {
+		options.Clock = clock
+		return options
+	}
+}
+
+// WithJobIdFunc override how job id of a run is generated.
+func WithJobIdFunc(jobIdFunc func(jobName string, scheduledTime time.Time) string) OptionPreparer {
+	return func(options *Options) *Options {
+		options.JobIdFunc = jobIdFunc
+		return options
+	}
+}
+
+// WithJobOptions applies jobOptions to each job instance started.
+func WithJobOptions(jobOptions ...asyncjob.JobOptionPreparer) OptionPreparer {
+	return func(options *Options) *Options {
+		options.JobOptions = append(options.JobOptions, jobOptions...)
+		return options
+	}
+}
+
+// WithHistory keeps n recent runs for Runs().
+func WithHistory(n int) OptionPreparer {
+	return func(options *Options) *Options {
+		options.History = n
+		return options
+	}
+}
+
+// Scheduler starts job instances of a job definition on a schedule, once started with Start.
+type Scheduler[T any] struct {
+	definition   *asyncjob.JobDefinition[T]
+	inputFactory InputFactory[T]
+	schedule     Schedule
+	options      *Options
+
+	mutex sync.Mutex
+	runs  []*Run[T]
+	// job instances started, removed once they are done.
+	active []*asyncjob.JobInstance[T]
+	// runs being started, not in active yet.
+	starting int
+	queue    []time.Time
+	stop     context.CancelFunc
+	// closed once loop returned.
+	loopDone chan struct{}
+	// stopped is set by Stop, no run is started or queued after it.
+	stopped bool
+}
+
+func New[T any](definition *asyncjob.JobDefinition[T], inputFactory InputFactory[T], schedule Schedule, options ...OptionPreparer) *Scheduler[T] {
+	s := &Scheduler[T]{
+		definition:   definition,
+		inputFactory: inputFactory,
+		schedule:     schedule,
+		options:      &Options{},
+	}
+
+	for _, decorator := range options {
+		s.options = decorator(s.options)
+	}
+
+	if s.options.OverlapPolicy == "" {
+		s.options.OverlapPolicy = OverlapSkip
+	}
+	if s.options.MissedRunPolicy == "" {
+		s.options.MissedRunPolicy = MissedRunSkip
+	}
+	if s.options.Clock == nil {
+		s.options.Clock = asyncjob.RealClock{}
+	}
+	if s.options.JobIdFunc == nil {
+		s.options.JobIdFunc = defaultJobId
+	}
+	if s.options.History <= 0 {
+		s.options.History = 100
+	}
+
+	return s
+}
+
+func defaultJobId(jobName string, scheduledTime time.Time) string {
+	return fmt.Sprintf("%s-%s", jobName, scheduledTime.UTC().Format("20060102T150405Z"))
+}
+
+// Start schedules runs from now on, until Stop is called or ctx is done, job instances are started with ctx.
+//
+//	a stopped scheduler cannot be started again.
+func (s *Scheduler[T]) Start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stop != nil || s.stopped {
+		return
+	}
+
+	loopCtx, stop := context.WithCancel(ctx)
+	s.stop = stop
+	s.loopDone = make(chan struct{})
+	go s.loop(ctx, loopCtx, s.options.Clock.Now())
+}
+
+// Stop stops scheduling runs and drops queued runs (RunStatusDropped), it doesn't cancel running job instances.
+func (s *Scheduler[T]) Stop() {
+	s.mutex.Lock()
+	s.stopped = true
+	for _, scheduledTime := range s.queue {
+		s.recordLocked(&Run[T]{ScheduledTime: scheduledTime, JobId: s.jobId(scheduledTime), Status: RunStatusDropped})
+	}
+	s.queue = nil
+	stop, loopDone := s.stop, s.loopDone
+	s.mutex.Unlock()
+
+	if stop != nil {
+		stop()
+		<-loopDone
+	}
+}
+
+// Runs returns recent runs, in order of their scheduled time.
+func (s *Scheduler[T]) Runs() []*Run[T] {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	runs := make([]*Run[T], 0, len(s.runs))
+	for _, run := range s.runs {
+		runCopy := *run
+		runs = append(runs, &runCopy)
+	}
+	return runs
+}
+
+func (s *Scheduler[T]) loop(jobCtx, loopCtx context.Context, last time.Time) {
+	defer close(s.loopDone)
+
+	clock := s.options.Clock
+	for {
+		next := s.schedule.Next(last)
+		if next.IsZero() {
+			return
+		}
+
+		select {
+		case <-clock.After(next.Sub(clock.Now())):
+		case <-loopCtx.Done():
+			return
+		}
+
+		// runs due by now, all but the latest are missed.
+		now := clock.Now()
+		due := []time.Time{next}
+		for t := s.schedule.Next(next); !t.IsZero() && !t.After(now); t = s.schedule.Next(t) {
+			due = append(due, t)
+		}
+		last = due[len(due)-1]
+
+		for i, scheduledTime := range due {
+			if i < len(due)-1 && s.options.MissedRunPolicy == MissedRunSkip {
+				s.record(&Run[T]{ScheduledTime: scheduledTime, JobId: s.jobId(scheduledTime), Status: RunStatusSkippedMissed})
+				continue
+			}
+			s.fire(jobCtx, scheduledTime)
+		}
+	}
+}
+
+// fire starts the run scheduled at scheduledTime, or skips or queues it by OverlapPolicy, nothing happens once stopped.
+func (s *Scheduler[T]) fire(ctx context.Context, scheduledTime time.Time) {
+	s.mutex.Lock()
+	if s.stopped {
+		s.mutex.Unlock()
+		return
+	}
+	if s.options.OverlapPolicy != OverlapAllow && (s.busyLocked() || len(s.queue) > 0) {
+		run := &Run[T]{ScheduledTime: scheduledTime, JobId: s.jobId(scheduledTime), Status: RunStatusSkippedOverlap}
+		if s.options.OverlapPolicy == OverlapQueue {
+			run.Status = RunStatusQueued
+			s.queue = append(s.queue, scheduledTime)
+		}
+		s.recordLocked(run)
+		s.mutex.Unlock()
+		return
+	}
+	s.starting++
+	s.mutex.Unlock()
+
+	s.startRun(ctx, scheduledTime)
+}
+
+// startRun starts job instance of the run, caller must have counted it in starting.
+func (s *Scheduler[T]) startRun(ctx context.Context, scheduledTime time.Time) {
+	run := &Run[T]{ScheduledTime: scheduledTime, JobId: s.jobId(scheduledTime), Status: RunStatusStarted}
+
+	input, err := s.inputFactory(ctx, scheduledTime)
+	if err == nil {
+		run.Instance = s.definition.Start(ctx, input, append(s.options.JobOptions, asyncjob.WithJobId(run.JobId))...)
+	} else {
+		run.Status = RunStatusFailedToStart
+		run.Error = err
+	}
+
+	s.mutex.Lock()
+	s.starting--
+	if run.Instance != nil {
+		s.active = append(s.active, run.Instance)
+	}
+	s.recordLocked(run)
+	s.mutex.Unlock()
+
+	if s.options.OverlapPolicy != OverlapQueue {
+		return
+	}
+	if run.Instance == nil {
+		s.dequeue(ctx)
+		return
+	}
+	go func() {
+		<-run.Instance.Done()
+		s.dequeue(ctx)
+	}()
+}
+
+// dequeue starts next queued run, if no run is running.
+func (s *Scheduler[T]) dequeue(ctx context.Context) {
+	s.mutex.Lock()
+	if s.stopped || len(s.queue) == 0 || s.busyLocked() {
+		s.mutex.Unlock()
+		return
+	}
+
+	scheduledTime := s.queue[0]
+	s.queue = s.queue[1:]
+	s.starting++
+	s.mutex.Unlock()
+
+	s.startRun(ctx, scheduledTime)
+}
+
+// busyLocked tells if any run is starting or running, caller must hold the mutex.
+func (s *Scheduler[T]) busyLocked() bool {
+	active := s.active[:0]
+	for _, instance := range s.active {
+		select {
+		case <-instance.Done():
+		default:
+			active = append(active, instance)
+		}
+	}
+	s.active = active
+
+	return len(s.active)+s.starting > 0
+}
+
+func (s *Scheduler[T]) jobId(scheduledTime time.Time) string {
+	return s.options.JobIdFunc(s.definition.GetName(), scheduledTime)
+}
+
+func (s *Scheduler[T]) record(run *Run[T]) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.recordLocked(run)
+}
+
+// recordLocked adds or updates the run by scheduled time, caller must hold the mutex.
+func (s *Scheduler[T]) recordLocked(run *Run[T]) {
+	for i, existing := range s.runs {
+		if existing.ScheduledTime.Equal(run.ScheduledTime) {
+			s.runs[i] = run
+			return
+		}
+	}
+
+	s.runs = append(s.runs, run)
+	if len(s.runs) > s.options.History {
+		s.runs = s.runs[len(s.runs)-s.options.History:]
+	}
+}
@@ -0,0 +1,157 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asyncjob/asyncjobtest"
+	"github.com/Azure/go-asyncjob/scheduler"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// buildJob returns a job blocked until its input channel is closed.
+func buildJob(t *testing.T) *asyncjob.JobDefinition[chan struct{}] {
+	jd := asyncjob.NewJobDefinition[chan struct{}]("scheduledJob")
+	_, err := asyncjob.AddStep(jd, "Work", func(release chan struct{}) asynctask.AsyncFunc[bool] {
+		return func(ctx context.Context) (bool, error) {
+			<-release
+			return true, nil
+		}
+	})
+	assert.NoError(t, err)
+	return jd
+}
+
+// tick advances the clock to next run, and waits for the scheduler to wait for the one after.
+func tick(clock *asyncjobtest.FakeClock, d time.Duration) {
+	clock.BlockUntil(1)
+	clock.Advance(d)
+	clock.BlockUntil(1)
+}
+
+func statuses[T any](runs []*scheduler.Run[T]) []scheduler.RunStatus {
+	var result []scheduler.RunStatus
+	for _, run := range runs {
+		result = append(result, run.Status)
+	}
+	return result
+}
+
+func TestSchedulerOverlapSkip(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(start)
+	releases := map[time.Time]chan struct{}{}
+	inputFactory := func(ctx context.Context, scheduledTime time.Time) (chan struct{}, error) {
+		if scheduledTime.Equal(start.Add(4 * time.Minute)) {
+			return nil, errors.New("no input")
+		}
+		releases[scheduledTime] = make(chan struct{})
+		return releases[scheduledTime], nil
+	}
+	every, err := scheduler.Every(time.Minute)
+	assert.NoError(t, err)
+	s := scheduler.New(buildJob(t), inputFactory, every, scheduler.WithClock(clock))
+	s.Start(context.Background())
+	defer s.Stop()
+
+	tick(clock, time.Minute)
+	tick(clock, time.Minute)
+	close(releases[start.Add(time.Minute)])
+	<-s.Runs()[0].Instance.Done()
+	tick(clock, time.Minute)
+	close(releases[start.Add(3*time.Minute)])
+	<-s.Runs()[2].Instance.Done()
+	tick(clock, time.Minute)
+
+	runs := s.Runs()
+	assert.Equal(t, []scheduler.RunStatus{scheduler.RunStatusStarted, scheduler.RunStatusSkippedOverlap, scheduler.RunStatusStarted, scheduler.RunStatusFailedToStart}, statuses(runs))
+	assert.Equal(t, "scheduledJob-20240301T000100Z", runs[0].JobId)
+	assert.Equal(t, runs[0].JobId, runs[0].Instance.GetJobInstanceId())
+	assert.EqualError(t, runs[3].Error, "no input")
+}
+
+func TestSchedulerOverlapQueue(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(start)
+	release := make(chan struct{})
+	inputFactory := func(ctx context.Context, scheduledTime time.Time) (chan struct{}, error) { return release, nil }
+	every, err := scheduler.Every(time.Minute)
+	assert.NoError(t, err)
+	s := scheduler.New(buildJob(t), inputFactory, every, scheduler.WithClock(clock), scheduler.WithOverlapPolicy(scheduler.OverlapQueue))
+	s.Start(context.Background())
+	defer s.Stop()
+
+	tick(clock, time.Minute)
+	tick(clock, time.Minute)
+	tick(clock, time.Minute)
+	assert.Equal(t, []scheduler.RunStatus{scheduler.RunStatusStarted, scheduler.RunStatusQueued, scheduler.RunStatusQueued}, statuses(s.Runs()))
+
+	// queued runs start one after another.
+	close(release)
+	assert.Eventually(t, func() bool { return s.Runs()[2].Status == scheduler.RunStatusStarted }, time.Second, time.Millisecond)
+	runs := s.Runs()
+	<-runs[2].Instance.Done()
+	assert.True(t, runs[1].Instance.ExecutionData().EndTime.Before(runs[2].Instance.ExecutionData().StartTime) ||
+		runs[1].Instance.ExecutionData().EndTime.Equal(runs[2].Instance.ExecutionData().StartTime))
+}
+
+func TestSchedulerStopDropsQueuedRuns(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(start)
+	release := make(chan struct{})
+	inputFactory := func(ctx context.Context, scheduledTime time.Time) (chan struct{}, error) { return release, nil }
+	every, err := scheduler.Every(time.Minute)
+	assert.NoError(t, err)
+	s := scheduler.New(buildJob(t), inputFactory, every, scheduler.WithClock(clock), scheduler.WithOverlapPolicy(scheduler.OverlapQueue))
+	s.Start(context.Background())
+
+	tick(clock, time.Minute)
+	tick(clock, time.Minute)
+	s.Stop()
+	assert.Equal(t, []scheduler.RunStatus{scheduler.RunStatusStarted, scheduler.RunStatusDropped}, statuses(s.Runs()))
+
+	// the running job finishes, dropped run is not started, and a stopped scheduler is not started again.
+	close(release)
+	<-s.Runs()[0].Instance.Done()
+	s.Start(context.Background())
+	clock.Advance(time.Minute)
+	assert.Never(t, func() bool { return len(s.Runs()) != 2 || s.Runs()[1].Status != scheduler.RunStatusDropped }, 20*time.Millisecond, time.Millisecond)
+}
+
+func TestSchedulerMissedRuns(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		policy   scheduler.MissedRunPolicy
+		expected []scheduler.RunStatus
+	}{
+		{scheduler.MissedRunSkip, []scheduler.RunStatus{scheduler.RunStatusSkippedMissed, scheduler.RunStatusSkippedMissed, scheduler.RunStatusStarted}},
+		{scheduler.MissedRunCatchUp, []scheduler.RunStatus{scheduler.RunStatusStarted, scheduler.RunStatusStarted, scheduler.RunStatusStarted}},
+	} {
+		clock := asyncjobtest.NewFakeClock(start)
+		release := make(chan struct{})
+		close(release)
+		inputFactory := func(ctx context.Context, scheduledTime time.Time) (chan struct{}, error) { return release, nil }
+		hourly, err := scheduler.Cron("@hourly")
+		assert.NoError(t, err)
+		s := scheduler.New(buildJob(t), inputFactory, hourly, scheduler.WithClock(clock), scheduler.WithMissedRunPolicy(test.policy), scheduler.WithOverlapPolicy(scheduler.OverlapAllow))
+		s.Start(context.Background())
+
+		// clock jumps over 3 runs.
+		tick(clock, 3*time.Hour+time.Minute)
+		s.Stop()
+
+		runs := s.Runs()
+		assert.Equal(t, test.expected, statuses(runs), test.policy)
+		assert.Equal(t, start.Add(3*time.Hour), runs[2].ScheduledTime)
+	}
+}
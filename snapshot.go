@@ -0,0 +1,68 @@
+package asyncjob
+
+import (
+	"sort"
+)
+
+// JobSnapshot is a point in time, serializable view of a job instance.
+type JobSnapshot struct {
+	JobId         string
+	JobName       string
+	Attempt       int
+	State         JobState
+	ExecutionData *JobExecutionData
+	Steps         []*StepSnapshot
+}
+
+// StepSnapshot is a point in time, serializable view of a step instance.
+type StepSnapshot struct {
+	Name          string
+	Description   string
+	Owner         string
+	Tags          []string
+	DependsOn     []string
+	State         StepState
+	ExecutionData *StepExecutionData
+	// Progress last reported by the step, nil if it never reported.
+	Progress *StepProgress
+	// Error of a failed step.
+	Error string
+}
+
+// Snapshot returns current state of the job instance and its steps, steps are sorted by name, root step is excluded.
+func (ji *JobInstance[T]) Snapshot() *JobSnapshot {
+	ji.mutex.RLock()
+	snapshot := &JobSnapshot{
+		JobId:         ji.GetJobInstanceId(),
+		JobName:       ji.Definition.GetName(),
+		Attempt:       ji.attempt,
+		State:         ji.state,
+		ExecutionData: ji.executionData.copy(),
+	}
+	ji.mutex.RUnlock()
+
+	for _, step := range ji.steps {
+		if step == ji.rootStep {
+			continue
+		}
+
+		stepDef := step.GetStepDefinition()
+		stepSnapshot := &StepSnapshot{
+			Name:          step.GetName(),
+			Description:   stepDef.GetDescription(),
+			Owner:         stepDef.GetOwner(),
+			Tags:          stepDef.GetTags(),
+			DependsOn:     ji.precedingSteps(stepDef),
+			State:         step.GetState(),
+			ExecutionData: step.ExecutionData(),
+			Progress:      step.Progress(),
+		}
+		if err := step.getError(); err != nil {
+			stepSnapshot.Error = err.Error()
+		}
+		snapshot.Steps = append(snapshot.Steps, stepSnapshot)
+	}
+	sort.Slice(snapshot.Steps, func(i, j int) bool { return snapshot.Steps[i].Name < snapshot.Steps[j].Name })
+
+	return snapshot
+}
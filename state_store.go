@@ -0,0 +1,166 @@
+package asyncjob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StateStore persists progress of job instances, so a crash leaves a complete record.
+//
+//	job input is saved once when job instance starts,
+//	step state is saved on every state change, together with the step output once it's completed.
+type StateStore interface {
+	// SaveJob records the job instance and it's (encoded) input, Steps on the checkpoint is ignored,
+	//   steps saved earlier are kept, Resume and RetryFailed continue from them.
+	SaveJob(ctx context.Context, job *JobCheckpoint) error
+
+	// ResetJob is same as SaveJob, but removes steps saved by an earlier run of the job id,
+	//   a new run from Start must not reuse step outputs computed from a different input.
+	ResetJob(ctx context.Context, job *JobCheckpoint) error
+
+	// CreateJob is same as SaveJob, but fails with ErrDuplicateJobId if the job is saved already,
+	//   the check and the write must be atomic, StartIdempotent relies on it to start a job id only once.
+	CreateJob(ctx context.Context, job *JobCheckpoint) error
+
+	// SaveStep records latest state of a step, keyed by (jobId, step name).
+	SaveStep(ctx context.Context, jobId string, step *StepCheckpoint) error
+
+	// LoadJob returns the checkpoint of a job, with all steps saved so far.
+	//   returns ErrCheckpointNotFound if the job is never saved.
+	LoadJob(ctx context.Context, jobId string) (*JobCheckpoint, error)
+}
+
+// JobCheckpoint is the saved record of a job instance.
+type JobCheckpoint struct {
+	JobId   string
+	JobName string
+	Input   []byte
+	// Shape of the job definition when checkpoint was written, step name to it's preceding step names.
+	Shape map[string][]string
+	// Attempt number of the run that wrote the checkpoint, see JobInstance.GetAttempt.
+	Attempt int
+	Steps   map[string]*StepCheckpoint
+}
+
+// attempt returns Attempt of the checkpoint, checkpoints written without it are from the first attempt.
+func (c *JobCheckpoint) attempt() int {
+	if c.Attempt < 1 {
+		return 1
+	}
+
+	return c.Attempt
+}
+
+// StepCheckpoint is the saved record of a step instance.
+type StepCheckpoint struct {
+	StepName  string
+	State     StepState
+	StartTime time.Time
+	Duration  time.Duration
+	// encoded step output, only available when step is completed.
+	Output []byte
+	// error message, only available when step is failed.
+	Error string
+}
+
+// MemoryStateStore is a StateStore keeping checkpoints in memory.
+//
+//	it doesn't survive process restart, useful for testing and for retrying jobs within a process.
+type MemoryStateStore struct {
+	jobs  map[string]*JobCheckpoint
+	mutex sync.RWMutex
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		jobs: make(map[string]*JobCheckpoint),
+	}
+}
+
+func (s *MemoryStateStore) SaveJob(ctx context.Context, job *JobCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	steps := make(map[string]*StepCheckpoint)
+	if existing, ok := s.jobs[job.JobId]; ok {
+		steps = existing.Steps
+	}
+
+	s.jobs[job.JobId] = &JobCheckpoint{
+		JobId:   job.JobId,
+		JobName: job.JobName,
+		Input:   job.Input,
+		Shape:   job.Shape,
+		Attempt: job.Attempt,
+		Steps:   steps,
+	}
+	return nil
+}
+
+func (s *MemoryStateStore) ResetJob(ctx context.Context, job *JobCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.jobs[job.JobId] = &JobCheckpoint{
+		JobId:   job.JobId,
+		JobName: job.JobName,
+		Input:   job.Input,
+		Shape:   job.Shape,
+		Attempt: job.Attempt,
+		Steps:   make(map[string]*StepCheckpoint),
+	}
+	return nil
+}
+
+func (s *MemoryStateStore) CreateJob(ctx context.Context, job *JobCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.jobs[job.JobId]; ok {
+		return ErrDuplicateJobId.WithMessage(fmt.Sprintf(MsgDuplicateJobId, job.JobId))
+	}
+
+	s.jobs[job.JobId] = &JobCheckpoint{
+		JobId:   job.JobId,
+		JobName: job.JobName,
+		Input:   job.Input,
+		Shape:   job.Shape,
+		Attempt: job.Attempt,
+		Steps:   make(map[string]*StepCheckpoint),
+	}
+	return nil
+}
+
+func (s *MemoryStateStore) SaveStep(ctx context.Context, jobId string, step *StepCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, ok := s.jobs[jobId]
+	if !ok {
+		return ErrCheckpointNotFound.WithMessage(fmt.Sprintf(MsgCheckpointNotFound, jobId))
+	}
+
+	stepCopy := *step
+	job.Steps[step.StepName] = &stepCopy
+	return nil
+}
+
+func (s *MemoryStateStore) LoadJob(ctx context.Context, jobId string) (*JobCheckpoint, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	job, ok := s.jobs[jobId]
+	if !ok {
+		return nil, ErrCheckpointNotFound.WithMessage(fmt.Sprintf(MsgCheckpointNotFound, jobId))
+	}
+
+	jobCopy := *job
+	jobCopy.Steps = make(map[string]*StepCheckpoint, len(job.Steps))
+	for name, step := range job.Steps {
+		stepCopy := *step
+		jobCopy.Steps[name] = &stepCopy
+	}
+	return &jobCopy, nil
+}
@@ -0,0 +1,214 @@
+package asyncjob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const fileStateStoreJobFile = "job.json"
+const fileStateStoreStepsDir = "steps"
+
+// FileStateStore is a StateStore keeping checkpoints in local files.
+//
+//	each job have it's own folder under rootDir, with job.json and steps/<stepName>.json
+//	files are written to a temp file and renamed, so a crash never leaves a partial record.
+//	CreateJob links the temp file instead, which fails if job.json exists, also across processes sharing rootDir.
+type FileStateStore struct {
+	rootDir string
+	mutex   sync.Mutex
+}
+
+// NewFileStateStore creates a FileStateStore under rootDir, rootDir is created if not exists.
+func NewFileStateStore(rootDir string) (*FileStateStore, error) {
+	if err := os.MkdirAll(rootDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileStateStore{rootDir: rootDir}, nil
+}
+
+func (s *FileStateStore) SaveJob(ctx context.Context, job *JobCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobDir, err := s.jobDir(job.JobId)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(jobDir, fileStateStoreStepsDir), 0o755); err != nil {
+		return err
+	}
+
+	return writeJSONFile(filepath.Join(jobDir, fileStateStoreJobFile), &JobCheckpoint{
+		JobId:   job.JobId,
+		JobName: job.JobName,
+		Input:   job.Input,
+		Shape:   job.Shape,
+		Attempt: job.Attempt,
+	})
+}
+
+func (s *FileStateStore) ResetJob(ctx context.Context, job *JobCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobDir, err := s.jobDir(job.JobId)
+	if err != nil {
+		return err
+	}
+	// steps are removed before job.json is replaced, a crash in between never pairs old steps with the new input.
+	if err := os.RemoveAll(filepath.Join(jobDir, fileStateStoreStepsDir)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(jobDir, fileStateStoreStepsDir), 0o755); err != nil {
+		return err
+	}
+
+	return writeJSONFile(filepath.Join(jobDir, fileStateStoreJobFile), &JobCheckpoint{
+		JobId:   job.JobId,
+		JobName: job.JobName,
+		Input:   job.Input,
+		Shape:   job.Shape,
+		Attempt: job.Attempt,
+	})
+}
+
+func (s *FileStateStore) CreateJob(ctx context.Context, job *JobCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobDir, err := s.jobDir(job.JobId)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(jobDir, fileStateStoreStepsDir), 0o755); err != nil {
+		return err
+	}
+
+	err = writeJSONFileWith(filepath.Join(jobDir, fileStateStoreJobFile), &JobCheckpoint{
+		JobId:   job.JobId,
+		JobName: job.JobName,
+		Input:   job.Input,
+		Shape:   job.Shape,
+		Attempt: job.Attempt,
+	}, os.Link)
+	if errors.Is(err, fs.ErrExist) {
+		return ErrDuplicateJobId.WithMessage(fmt.Sprintf(MsgDuplicateJobId, job.JobId))
+	}
+	return err
+}
+
+func (s *FileStateStore) SaveStep(ctx context.Context, jobId string, step *StepCheckpoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobDir, err := s.jobDir(jobId)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(jobDir, fileStateStoreJobFile)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrCheckpointNotFound.WithMessage(fmt.Sprintf(MsgCheckpointNotFound, jobId))
+		}
+		return err
+	}
+
+	return writeJSONFile(filepath.Join(jobDir, fileStateStoreStepsDir, url.PathEscape(step.StepName)+".json"), step)
+}
+
+func (s *FileStateStore) LoadJob(ctx context.Context, jobId string) (*JobCheckpoint, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobDir, err := s.jobDir(jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &JobCheckpoint{}
+	if err := readJSONFile(filepath.Join(jobDir, fileStateStoreJobFile), job); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrCheckpointNotFound.WithMessage(fmt.Sprintf(MsgCheckpointNotFound, jobId))
+		}
+		return nil, err
+	}
+
+	stepsDir := filepath.Join(jobDir, fileStateStoreStepsDir)
+	entries, err := os.ReadDir(stepsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Steps = make(map[string]*StepCheckpoint, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		step := &StepCheckpoint{}
+		if err := readJSONFile(filepath.Join(stepsDir, entry.Name()), step); err != nil {
+			return nil, err
+		}
+		job.Steps[step.StepName] = step
+	}
+
+	return job, nil
+}
+
+// jobDir returns the folder of the job, ids escaping to "", "." or ".." are rejected, they don't name a folder under rootDir.
+func (s *FileStateStore) jobDir(jobId string) (string, error) {
+	escaped := url.PathEscape(jobId)
+	if escaped == "" || escaped == "." || escaped == ".." {
+		return "", ErrInvalidJobId.WithMessage(fmt.Sprintf(MsgInvalidJobId, jobId))
+	}
+
+	return filepath.Join(s.rootDir, escaped), nil
+}
+
+func writeJSONFile(path string, v any) error {
+	return writeJSONFileWith(path, v, os.Rename)
+}
+
+// writeJSONFileWith writes v to a temp file, then moves it to path with publish (os.Rename or os.Link).
+func writeJSONFileWith(path string, v any, publish func(oldpath, newpath string) error) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return publish(tmpFile.Name(), path)
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,234 @@
+package asyncjob_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	fileStore, err := asyncjob.NewFileStateStore(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, store := range []asyncjob.StateStore{asyncjob.NewMemoryStateStore(), fileStore} {
+		ctx := context.WithValue(context.Background(), testLoggingContextKey, t)
+		jobInstance := SqlSummaryAsyncJobDefinition.Start(ctx, NewSqlJobLib(&SqlSummaryJobParameters{
+			ServerName: "server1",
+			Table1:     "table1",
+			Query1:     "query1",
+			Table2:     "table2",
+			Query2:     "query2",
+			ErrorInjection: map[string]func() error{
+				"ExecuteQuery.server1.table2.query2": func() error { return fmt.Errorf("query exeeded memory limit") },
+			},
+		}), asyncjob.WithJobId("checkpointJob"), asyncjob.WithStateStore(store))
+		assert.Error(t, jobInstance.Wait(context.Background()))
+
+		checkpoint, err := store.LoadJob(context.Background(), "checkpointJob")
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlSummaryJob", checkpoint.JobName)
+
+		input := &SqlSummaryJobLib{}
+		assert.NoError(t, asyncjob.JSONCodec{}.Unmarshal(checkpoint.Input, input))
+		assert.Equal(t, "server1", input.Params.ServerName)
+
+		assert.Equal(t, asyncjob.StepStateCompleted, checkpoint.Steps["QueryTable1"].State)
+		queryResult := &SqlQueryResult{}
+		assert.NoError(t, asyncjob.JSONCodec{}.Unmarshal(checkpoint.Steps["QueryTable1"].Output, queryResult))
+		assert.Equal(t, "table1", queryResult.Data["tableName"])
+
+		assert.Equal(t, asyncjob.StepStateFailed, checkpoint.Steps["QueryTable2"].State)
+		assert.Contains(t, checkpoint.Steps["QueryTable2"].Error, "query exeeded memory limit")
+		assert.Nil(t, checkpoint.Steps["QueryTable2"].Output)
+
+		// Summarize never started
+		_, ok := checkpoint.Steps["Summarize"]
+		assert.False(t, ok)
+
+		_, err = store.LoadJob(context.Background(), "notExistingJob")
+		assert.ErrorIs(t, err, asyncjob.ErrCheckpointNotFound)
+
+		// CreateJob only succeeds once per job id, steps of the existing job are kept.
+		err = store.CreateJob(context.Background(), &asyncjob.JobCheckpoint{JobId: "checkpointJob", JobName: "sqlSummaryJob"})
+		assert.ErrorIs(t, err, asyncjob.ErrDuplicateJobId)
+		checkpoint, err = store.LoadJob(context.Background(), "checkpointJob")
+		assert.NoError(t, err)
+		assert.Equal(t, asyncjob.StepStateCompleted, checkpoint.Steps["QueryTable1"].State)
+		assert.NoError(t, store.CreateJob(context.Background(), &asyncjob.JobCheckpoint{JobId: "newJob", JobName: "sqlSummaryJob"}))
+		checkpoint, err = store.LoadJob(context.Background(), "newJob")
+		assert.NoError(t, err)
+		assert.Empty(t, checkpoint.Steps)
+	}
+}
+
+func TestFileStateStoreInvalidJobId(t *testing.T) {
+	t.Parallel()
+
+	parentDir := t.TempDir()
+	store, err := asyncjob.NewFileStateStore(filepath.Join(parentDir, "store"))
+	assert.NoError(t, err)
+
+	for _, jobId := range []string{"", ".", ".."} {
+		err := store.SaveJob(context.Background(), &asyncjob.JobCheckpoint{JobId: jobId, JobName: "job"})
+		assert.ErrorIs(t, err, asyncjob.ErrInvalidJobId)
+		err = store.CreateJob(context.Background(), &asyncjob.JobCheckpoint{JobId: jobId, JobName: "job"})
+		assert.ErrorIs(t, err, asyncjob.ErrInvalidJobId)
+		_, err = store.LoadJob(context.Background(), jobId)
+		assert.ErrorIs(t, err, asyncjob.ErrInvalidJobId)
+	}
+	_, err = os.Stat(filepath.Join(parentDir, "job.json"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// escaped ids stay under rootDir.
+	assert.NoError(t, store.SaveJob(context.Background(), &asyncjob.JobCheckpoint{JobId: "../job", JobName: "job"}))
+	_, err = store.LoadJob(context.Background(), "../job")
+	assert.NoError(t, err)
+}
+
+func TestJobCheckpointStoreFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(context.Background(), testLoggingContextKey, t)
+	jobInstance := SqlSummaryAsyncJobDefinition.Start(ctx, NewSqlJobLib(&SqlSummaryJobParameters{
+		ServerName: "server1",
+		Table1:     "table1",
+		Query1:     "query1",
+		Table2:     "table2",
+		Query2:     "query2",
+	}), asyncjob.WithStateStore(&failingStateStore{}))
+
+	err := jobInstance.Wait(context.Background())
+	assert.Error(t, err)
+
+	jobErr := &asyncjob.JobError{}
+	assert.True(t, errors.As(err, &jobErr))
+	assert.Equal(t, asyncjob.ErrStateStoreFailed, jobErr.Code)
+	assert.Equal(t, "sqlSummaryJob", jobErr.StepInstance.GetName())
+	assert.Equal(t, asyncjob.JobStateFailed, jobInstance.GetState())
+}
+
+type failingStateStore struct{}
+
+func (s *failingStateStore) SaveJob(ctx context.Context, job *asyncjob.JobCheckpoint) error {
+	return fmt.Errorf("disk full")
+}
+
+func (s *failingStateStore) ResetJob(ctx context.Context, job *asyncjob.JobCheckpoint) error {
+	return fmt.Errorf("disk full")
+}
+
+func (s *failingStateStore) CreateJob(ctx context.Context, job *asyncjob.JobCheckpoint) error {
+	return fmt.Errorf("disk full")
+}
+
+func (s *failingStateStore) SaveStep(ctx context.Context, jobId string, step *asyncjob.StepCheckpoint) error {
+	return fmt.Errorf("disk full")
+}
+
+func (s *failingStateStore) LoadJob(ctx context.Context, jobId string) (*asyncjob.JobCheckpoint, error) {
+	return nil, fmt.Errorf("disk full")
+}
+
+func TestJobResume(t *testing.T) {
+	t.Parallel()
+
+	store := asyncjob.NewMemoryStateStore()
+	ctx := context.WithValue(context.Background(), testLoggingContextKey, t)
+	jobInstance := SqlSummaryAsyncJobDefinition.Start(ctx, NewSqlJobLib(&SqlSummaryJobParameters{
+		ServerName: "server1",
+		Table1:     "table1",
+		Query1:     "query1",
+		Table2:     "table2",
+		Query2:     "query2",
+		ErrorInjection: map[string]func() error{
+			"ExecuteQuery.server1.table2.query2": func() error { return fmt.Errorf("query exeeded memory limit") },
+		},
+	}), asyncjob.WithJobId("resumeJob"), asyncjob.WithStateStore(store))
+	assert.Error(t, jobInstance.Wait(context.Background()))
+
+	// error injection is not part of the checkpoint, so resumed job will succeed.
+	resumedInstance, err := SqlSummaryAsyncJobDefinition.Resume(ctx, "resumeJob", store)
+	assert.NoError(t, err)
+	assert.NoError(t, resumedInstance.Wait(context.Background()))
+	assert.Equal(t, asyncjob.JobStateSucceeded, resumedInstance.GetState())
+	assert.Equal(t, "resumeJob", resumedInstance.GetJobInstanceId())
+	assert.Equal(t, 2, resumedInstance.GetAttempt())
+	assert.Equal(t, 2, resumedInstance.Snapshot().Attempt)
+	result, err := resumedInstance.Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "table2", result.QueryResult2["tableName"])
+	renderGraph(t, resumedInstance)
+
+	// completed steps are not executed again
+	for _, stepName := range []string{"GetConnection", "CheckAuth", "GetTableClient1", "GetTableClient2", "QueryTable1"} {
+		originalStep, _ := jobInstance.GetStepInstance(stepName)
+		resumedStep, _ := resumedInstance.GetStepInstance(stepName)
+		assert.Equal(t, asyncjob.StepStateCompleted, resumedStep.GetState())
+		assert.True(t, originalStep.ExecutionData().StartTime.Equal(resumedStep.ExecutionData().StartTime), stepName)
+	}
+
+	originalStep, _ := jobInstance.GetStepInstance("QueryTable2")
+	resumedStep, _ := resumedInstance.GetStepInstance("QueryTable2")
+	assert.True(t, resumedStep.ExecutionData().StartTime.After(originalStep.ExecutionData().StartTime))
+
+	checkpoint, err := store.LoadJob(context.Background(), "resumeJob")
+	assert.NoError(t, err)
+	assert.Equal(t, asyncjob.StepStateCompleted, checkpoint.Steps["Summarize"].State)
+	assert.Equal(t, 2, checkpoint.Attempt)
+
+	// definition with different shape cannot resume
+	changedJob := asyncjob.NewJobDefinition[*SqlSummaryJobLib]("sqlSummaryJob")
+	_, err = asyncjob.AddStep(changedJob, "GetConnection", connectionStepFunc)
+	assert.NoError(t, err)
+	_, err = changedJob.Resume(ctx, "resumeJob", store)
+	assert.ErrorIs(t, err, asyncjob.ErrCheckpointShapeMismatch)
+	assert.Contains(t, err.Error(), `step "Summarize" is removed`)
+
+	_, err = SqlSummaryAsyncJobDefinition.Resume(ctx, "notExistingJob", store)
+	assert.ErrorIs(t, err, asyncjob.ErrCheckpointNotFound)
+}
+
+func TestJobStartResetsCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	fileStore, err := asyncjob.NewFileStateStore(t.TempDir())
+	assert.NoError(t, err)
+
+	jd := asyncjob.NewJobDefinition[string]("echoJob")
+	_, err = asyncjob.AddStep(jd, "Echo", func(input string) asynctask.AsyncFunc[string] {
+		return func(ctx context.Context) (string, error) { return input, nil }
+	})
+	assert.NoError(t, err)
+
+	for _, store := range []asyncjob.StateStore{asyncjob.NewMemoryStateStore(), fileStore} {
+		assert.NoError(t, jd.Start(context.Background(), "first", asyncjob.WithJobId("echo"), asyncjob.WithStateStore(store)).Wait(context.Background()))
+
+		// a new run of the same job id, cancelled before Echo completes.
+		cancelledCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Error(t, jd.Start(cancelledCtx, "second", asyncjob.WithJobId("echo"), asyncjob.WithStateStore(store)).Wait(context.Background()))
+		checkpoint, err := store.LoadJob(context.Background(), "echo")
+		assert.NoError(t, err)
+		if echo, ok := checkpoint.Steps["Echo"]; ok {
+			assert.NotEqual(t, asyncjob.StepStateCompleted, echo.State)
+		}
+
+		// resume runs Echo with input of the new run, instead of reusing output of the first run.
+		resumed, err := jd.Resume(context.Background(), "echo", store)
+		assert.NoError(t, err)
+		assert.NoError(t, resumed.Wait(context.Background()))
+		checkpoint, err = store.LoadJob(context.Background(), "echo")
+		assert.NoError(t, err)
+		assert.Equal(t, `"second"`, string(checkpoint.Steps["Echo"].Output))
+	}
+}
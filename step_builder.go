@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
-	"time"
 
 	"github.com/Azure/go-asynctask"
 )
@@ -63,7 +62,8 @@ func StepAfter[JT, PT, ST any](j *JobDefinition[JT], stepName string, parentStep
 		return nil, err
 	}
 
-	stepD := newStepDefinition[ST](stepName, stepTypeTask, append(optionDecorators, ExecuteAfter(parentStep))...)
+	stepD := newStepDefinition[ST](stepName, stepTypeAfter, append(optionDecorators, ExecuteAfter(parentStep))...)
+	stepD.inputSteps = []string{parentStep.GetName()}
 	precedingDefSteps, err := getDependsOnSteps(j, stepD.DependsOn())
 	if err != nil {
 		return nil, err
@@ -112,7 +112,8 @@ func StepAfterBoth[JT, PT1, PT2, ST any](j *JobDefinition[JT], stepName string,
 		return nil, ErrDuplicateInputParentStep.WithMessage(MsgDuplicateInputParentStep)
 	}
 
-	stepD := newStepDefinition[ST](stepName, stepTypeTask, append(optionDecorators, ExecuteAfter(parentStep1), ExecuteAfter(parentStep2))...)
+	stepD := newStepDefinition[ST](stepName, stepTypeAfterBoth, append(optionDecorators, ExecuteAfter(parentStep1), ExecuteAfter(parentStep2))...)
+	stepD.inputSteps = []string{parentStep1.GetName(), parentStep2.GetName()}
 	precedingDefSteps, err := getDependsOnSteps(j, stepD.DependsOn())
 	if err != nil {
 		return nil, err
@@ -167,105 +168,108 @@ func StepAfterBothWithStaticFunc[JT, PT1, PT2, ST any](j *JobDefinition[JT], ste
 
 func instrumentedAddStep[T any](stepInstance *StepInstance[T], precedingTasks []asynctask.Waitable, stepFunc func(ctx context.Context) (T, error)) func(ctx context.Context) (T, error) {
 	return func(ctx context.Context) (T, error) {
-		if err := asynctask.WaitAll(ctx, &asynctask.WaitAllOptions{}, precedingTasks...); err != nil {
-			/* this only work on ExecuteAfter (have precedent step, but not taking input from it)
-			   asynctask.ContinueWith and asynctask.AfterBoth won't invoke instrumentedFunc if any of the preceding task failed.
-			   we need to be consistent on before we do any state change or error handling. */
-			return *new(T), err
-		}
-
-		stepInstance.executionData.StartTime = time.Now()
-		stepInstance.state = StepStateRunning
-		ctx = stepInstance.EnrichContext(ctx)
-
-		var result T
-		var err error
-		if stepInstance.Definition.executionOptions.RetryPolicy != nil {
-			stepInstance.executionData.Retried = &RetryReport{}
-			result, err = newRetryer(stepInstance.Definition.executionOptions.RetryPolicy, stepInstance.executionData.Retried, func() (T, error) { return stepFunc(ctx) }).Run()
-		} else {
-			result, err = stepFunc(ctx)
-		}
-
-		stepInstance.executionData.Duration = time.Since(stepInstance.executionData.StartTime)
-
-		if err != nil {
-			stepInstance.state = StepStateFailed
-			return *new(T), newStepError(ErrStepFailed, stepInstance, err)
-		} else {
-			stepInstance.state = StepStateCompleted
-			return result, nil
-		}
+		return instrumentedRun(ctx, stepInstance, precedingTasks, nil, stepFunc)
 	}
 }
 
 func instrumentedStepAfter[T, S any](stepInstance *StepInstance[S], precedingTasks []asynctask.Waitable, stepFunc func(ctx context.Context, t T) (S, error)) func(ctx context.Context, t T) (S, error) {
 	return func(ctx context.Context, t T) (S, error) {
-		if err := asynctask.WaitAll(ctx, &asynctask.WaitAllOptions{}, precedingTasks...); err != nil {
-			/* this only work on ExecuteAfter (have precedent step, but not taking input from it)
-			   asynctask.ContinueWith and asynctask.AfterBoth won't invoke instrumentedFunc if any of the preceding task failed.
-			   we need to be consistent on before we do any state change or error handling. */
-			return *new(S), err
-		}
+		return instrumentedRun(ctx, stepInstance, precedingTasks, []any{t}, func(ctx context.Context) (S, error) { return stepFunc(ctx, t) })
+	}
+}
 
-		stepInstance.executionData.StartTime = time.Now()
-		stepInstance.state = StepStateRunning
-		ctx = stepInstance.EnrichContext(ctx)
+func instrumentedStepAfterBoth[T, S, R any](stepInstance *StepInstance[R], precedingTasks []asynctask.Waitable, stepFunc func(ctx context.Context, t T, s S) (R, error)) func(ctx context.Context, t T, s S) (R, error) {
+	return func(ctx context.Context, t T, s S) (R, error) {
+		return instrumentedRun(ctx, stepInstance, precedingTasks, []any{t, s}, func(ctx context.Context) (R, error) { return stepFunc(ctx, t, s) })
+	}
+}
 
-		var result S
-		var err error
-		if stepInstance.Definition.executionOptions.RetryPolicy != nil {
-			stepInstance.executionData.Retried = &RetryReport{}
-			result, err = newRetryer(stepInstance.Definition.executionOptions.RetryPolicy, stepInstance.executionData.Retried, func() (S, error) { return stepFunc(ctx, t) }).Run()
-		} else {
-			result, err = stepFunc(ctx, t)
-		}
+// instrumentedRun is shared by all step kinds, inputs from preceding steps is already bound in stepFunc.
+//
+//	it waits for preceding tasks, then run stepFunc with state tracking, caching, retry and checkpointing.
+func instrumentedRun[T any](ctx context.Context, stepInstance *StepInstance[T], precedingTasks []asynctask.Waitable, inputs []any, stepFunc func(ctx context.Context) (T, error)) (T, error) {
+	if err := asynctask.WaitAll(ctx, &asynctask.WaitAllOptions{}, precedingTasks...); err != nil {
+		/* this only work on ExecuteAfter (have precedent step, but not taking input from it)
+		   asynctask.ContinueWith and asynctask.AfterBoth won't invoke instrumentedFunc if any of the preceding task failed.
+		   we need to be consistent on before we do any state change or error handling. */
+		return *new(T), err
+	}
 
-		stepInstance.executionData.Duration = time.Since(stepInstance.executionData.StartTime)
+	clock := stepInstance.JobInstance.getJobOptions().Clock
+	startTime := clock.Now()
+	stepInstance.updateExecutionData(func(executionData *StepExecutionData) { executionData.StartTime = startTime })
+	stepInstance.setState(StepStateRunning, nil)
+	if err := stepInstance.saveCheckpoint(ctx, *new(T), nil); err != nil {
+		stepInstance.setState(StepStateFailed, err)
+		return *new(T), newStepError(ErrStateStoreFailed, stepInstance, err)
+	}
+	ctx = withProgressReporter(ctx, stepInstance)
+	ctx = stepInstance.EnrichContext(ctx)
 
-		if err != nil {
-			stepInstance.state = StepStateFailed
-			return *new(S), newStepError(ErrStepFailed, stepInstance, err)
-		} else {
-			stepInstance.state = StepStateCompleted
-			return result, nil
-		}
+	// rate limit the step func, so an interceptor replacing it is not throttled.
+	if stepInstance.Definition.executionOptions.RateLimiter != "" {
+		stepFunc = rateLimitStepFunc(stepInstance, stepInstance.JobInstance.getJobOptions().RateLimiterRegistry, stepFunc)
 	}
-}
 
-func instrumentedStepAfterBoth[T, S, R any](stepInstance *StepInstance[R], precedingTasks []asynctask.Waitable, stepFunc func(ctx context.Context, t T, s S) (R, error)) func(ctx context.Context, t T, s S) (R, error) {
-	return func(ctx context.Context, t T, s S) (R, error) {
+	if interceptor := stepInstance.JobInstance.getJobOptions().StepInterceptor; interceptor != nil {
+		stepFunc = interceptStepFunc(stepInstance, interceptor, stepFunc)
+	}
 
-		if err := asynctask.WaitAll(ctx, &asynctask.WaitAllOptions{}, precedingTasks...); err != nil {
-			/* this only work on ExecuteAfter (have precedent step, but not taking input from it)
-			   asynctask.ContinueWith and asynctask.AfterBoth won't invoke instrumentedFunc if any of the preceding task failed.
-			   we need to be consistent on before we do any state change or error handling. */
-			return *new(R), err
+	capturePolicy := stepInstance.JobInstance.getJobOptions().CapturePolicy
+	if capturePolicy != nil {
+		captured := &StepCapture{}
+		for _, input := range inputs {
+			captured.Inputs = append(captured.Inputs, capturePolicy.capture(stepInstance.GetName(), input))
 		}
+		stepInstance.updateExecutionData(func(executionData *StepExecutionData) { executionData.Captured = captured })
+	}
 
-		stepInstance.executionData.StartTime = time.Now()
-		stepInstance.state = StepStateRunning
-		ctx = stepInstance.EnrichContext(ctx)
+	cachePolicy := stepInstance.Definition.executionOptions.CachePolicy
+	cacheKey := ""
+	if cachePolicy != nil {
+		// failed to compute cache key is treated as cache miss, step still runs.
+		cacheKey, _ = stepInstance.cacheKey(inputs)
+	}
 
-		var result R
-		var err error
-		if stepInstance.Definition.executionOptions.RetryPolicy != nil {
-			stepInstance.executionData.Retried = &RetryReport{}
-			result, err = newRetryer(stepInstance.Definition.executionOptions.RetryPolicy, stepInstance.executionData.Retried, func() (R, error) { return stepFunc(ctx, t, s) }).Run()
-		} else {
-			result, err = stepFunc(ctx, t, s)
-		}
+	var result T
+	var err error
+	cached, fromCache := stepInstance.getCachedOutput(cacheKey)
+	if fromCache {
+		result = cached
+		stepInstance.updateExecutionData(func(executionData *StepExecutionData) { executionData.Cached = true })
+	} else if stepInstance.Definition.executionOptions.RetryPolicy != nil {
+		retried := &RetryReport{}
+		stepInstance.updateExecutionData(func(executionData *StepExecutionData) { executionData.Retried = retried })
+		result, err = newRetryer(stepInstance.Definition.executionOptions.RetryPolicy, retried, &stepInstance.mutex, clock, func(state StepState) { stepInstance.setState(state, nil) }, stepInstance.rateLimitWait, func() (T, error) { return stepFunc(ctx) }).Run()
+	} else {
+		result, err = stepFunc(ctx)
+	}
+
+	if err == nil && cacheKey != "" && !fromCache {
+		cachePolicy.Cache.Set(cacheKey, result, cachePolicy.TTL)
+	}
 
-		stepInstance.executionData.Duration = time.Since(stepInstance.executionData.StartTime)
+	duration := clock.Since(startTime)
+	stepInstance.updateExecutionData(func(executionData *StepExecutionData) { executionData.Duration = duration })
 
-		if err != nil {
-			stepInstance.state = StepStateFailed
-			return *new(R), newStepError(ErrStepFailed, stepInstance, err)
-		} else {
-			stepInstance.state = StepStateCompleted
-			return result, nil
-		}
+	if err != nil {
+		stepInstance.setState(StepStateFailed, err)
+		// step already failed, failing to record that is not worth another error.
+		stepInstance.saveCheckpoint(ctx, *new(T), err)
+		return *new(T), newStepError(ErrStepFailed, stepInstance, err)
+	}
+
+	if capturePolicy != nil {
+		output := capturePolicy.capture(stepInstance.GetName(), result)
+		stepInstance.updateExecutionData(func(executionData *StepExecutionData) { executionData.Captured.Output = output })
 	}
+
+	stepInstance.setState(StepStateCompleted, nil)
+	if err := stepInstance.saveCheckpoint(ctx, result, nil); err != nil {
+		stepInstance.setState(StepStateFailed, err)
+		return *new(T), newStepError(ErrStateStoreFailed, stepInstance, err)
+	}
+	return result, nil
 }
 
 func addStepPreCheck(j JobDefinitionMeta, stepName string) error {
@@ -299,7 +303,11 @@ func getDependsOnStepInstances(stepD StepDefinitionMeta, ji JobInstanceMeta) ([]
 	for _, depStepName := range stepD.DependsOn() {
 		if depStep, ok := ji.GetStepInstance(depStepName); ok {
 			precedingInstances = append(precedingInstances, depStep)
-			precedingTasks = append(precedingTasks, depStep.Waitable())
+			if stepD.DependencyKind(depStepName) == EdgeKindOrder && stepD.getErrorPolicy().IgnoreOrderDependencyFailure {
+				precedingTasks = append(precedingTasks, finishedWaitable{depStep.Waitable()})
+			} else {
+				precedingTasks = append(precedingTasks, depStep.Waitable())
+			}
 		} else {
 			return nil, nil, ErrRuntimeStepNotFound.WithMessage(fmt.Sprintf(MsgRuntimeStepNotFound, depStepName))
 		}
@@ -308,6 +316,19 @@ func getDependsOnStepInstances(stepD StepDefinitionMeta, ji JobInstanceMeta) ([]
 	return precedingInstances, precedingTasks, nil
 }
 
+// finishedWaitable waits for the task to finish, succeeded or not.
+type finishedWaitable struct {
+	asynctask.Waitable
+}
+
+func (w finishedWaitable) Wait(ctx context.Context) error {
+	if err := w.Waitable.Wait(ctx); err != nil && ctx.Err() != nil {
+		return err
+	}
+
+	return nil
+}
+
 // this is most vulunerable point of this library
 //
 //	we have strongTyped steps
@@ -11,6 +11,14 @@ func TestDefinitionRendering(t *testing.T) {
 	t.Parallel()
 
 	renderGraph(t, SqlSummaryAsyncJobDefinition)
+
+	graphStr, err := SqlSummaryAsyncJobDefinition.Visualize(asyncjob.WithVisualizeFormat(asyncjob.VisualizeFormatMermaid))
+	assert.NoError(t, err)
+	t.Log(graphStr)
+	assert.Contains(t, graphStr, "flowchart TD")
+
+	_, err = SqlSummaryAsyncJobDefinition.Visualize(asyncjob.WithVisualizeFormat("svg"))
+	assert.ErrorIs(t, err, asyncjob.ErrUnsupportedVisualizeFormat)
 }
 
 func TestDefinitionBuilder(t *testing.T) {
@@ -0,0 +1,118 @@
+package asyncjob
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// StepCache stores step outputs, so steps with same input can skip execution across job instances.
+//
+//	cached outputs are shared between job instances, they should be treated as immutable.
+type StepCache interface {
+	// Get returns the cached value of key, false if not found or expired.
+	Get(key string) (any, bool)
+	// Set stores value with key, expires after ttl (0 for never).
+	Set(key string, value any, ttl time.Duration)
+}
+
+// CacheKeyFunc computes the cache key of a step,
+//
+//	stepInputs are outputs of the preceding steps the step takes input from (StepAfter, StepAfterBoth), empty for AddStep.
+type CacheKeyFunc func(jobInput any, stepInputs []any) (string, error)
+
+// HashCacheKey is the default CacheKeyFunc, it hash job input and step inputs encoded in json.
+func HashCacheKey(jobInput any, stepInputs []any) (string, error) {
+	encoded, err := json.Marshal(append([]any{jobInput}, stepInputs...))
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// LRUCache is an in-memory StepCache, least recently used entry is evicted when capacity is reached.
+type LRUCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	// most recently used entry at front.
+	order *list.List
+	mutex sync.Mutex
+	clock Clock
+}
+
+type lruCacheEntry struct {
+	key      string
+	value    any
+	expireAt time.Time
+}
+
+// NewLRUCache creates a LRUCache holding at most capacity entries.
+func NewLRUCache(capacity int) *LRUCache {
+	return NewLRUCacheWithClock(capacity, RealClock{})
+}
+
+// NewLRUCacheWithClock is same as NewLRUCache, entries expire by time of clock.
+func NewLRUCacheWithClock(capacity int, clock Clock) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		clock:    clock,
+	}
+}
+
+func (c *LRUCache) Get(key string) (any, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruCacheEntry)
+	if !entry.expireAt.IsZero() && c.clock.Now().After(entry.expireAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *LRUCache) Set(key string, value any, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := &lruCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expireAt = c.clock.Now().Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruCacheEntry).key)
+	}
+}
+
+// Len returns number of entries in the cache, including expired ones not yet evicted.
+func (c *LRUCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
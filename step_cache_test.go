@@ -0,0 +1,85 @@
+package asyncjob_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepCache(t *testing.T) {
+	t.Parallel()
+
+	var connections, lookups int32
+	cache := asyncjob.NewLRUCache(10)
+	jd := asyncjob.NewJobDefinition[string]("cachedJob")
+	connTsk, err := asyncjob.AddStep(jd, "GetConnection", func(serverName string) asynctask.AsyncFunc[*SqlConnection] {
+		return func(ctx context.Context) (*SqlConnection, error) {
+			atomic.AddInt32(&connections, 1)
+			return &SqlConnection{ServerName: serverName}, nil
+		}
+	}, asyncjob.WithCache(cache, nil, time.Minute))
+	assert.NoError(t, err)
+
+	_, err = asyncjob.StepAfterWithStaticFunc(jd, "GetTableMetadata", connTsk, func(ctx context.Context, conn *SqlConnection) (string, error) {
+		atomic.AddInt32(&lookups, 1)
+		return conn.ServerName + ".table1", nil
+	}, asyncjob.WithCache(cache, func(jobInput any, stepInputs []any) (string, error) {
+		return stepInputs[0].(*SqlConnection).ServerName, nil
+	}, time.Minute))
+	assert.NoError(t, err)
+
+	jobInstance1 := jd.Start(context.Background(), "server1")
+	assert.NoError(t, jobInstance1.Wait(context.Background()))
+	jobInstance2 := jd.Start(context.Background(), "server1")
+	assert.NoError(t, jobInstance2.Wait(context.Background()))
+	jobInstance3 := jd.Start(context.Background(), "server2")
+	assert.NoError(t, jobInstance3.Wait(context.Background()))
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connections))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+	assert.Equal(t, 4, cache.Len())
+
+	for _, stepName := range []string{"GetConnection", "GetTableMetadata"} {
+		step, _ := jobInstance1.GetStepInstance(stepName)
+		assert.False(t, step.ExecutionData().Cached)
+
+		step, _ = jobInstance2.GetStepInstance(stepName)
+		assert.True(t, step.ExecutionData().Cached)
+		assert.Equal(t, asyncjob.StepStateCompleted, step.GetState())
+		assert.True(t, strings.Contains(step.DotSpec().Tooltip, "Cached: true"))
+
+		step, _ = jobInstance3.GetStepInstance(stepName)
+		assert.False(t, step.ExecutionData().Cached)
+	}
+	renderGraph(t, jobInstance2)
+}
+
+func TestLRUCache(t *testing.T) {
+	t.Parallel()
+
+	cache := asyncjob.NewLRUCache(2)
+	cache.Set("a", 1, 0)
+	cache.Set("b", 2, 0)
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	// b is least recently used
+	cache.Set("c", 3, 0)
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	cache.Set("d", 4, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	_, ok = cache.Get("d")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+}
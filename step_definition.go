@@ -2,14 +2,32 @@ package asyncjob
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/Azure/go-asyncjob/graph"
+	"github.com/Azure/go-asynctask"
 )
 
 type stepType string
 
 const stepTypeTask stepType = "task"
 const stepTypeRoot stepType = "root"
+const stepTypeAfter stepType = "after"
+const stepTypeAfterBoth stepType = "afterBoth"
+const stepTypeSignal stepType = "signal"
+const stepTypeFinally stepType = "finally"
+
+// EdgeKind tells how a step depends on a preceding step.
+type EdgeKind string
+
+// EdgeKindData means the step takes output of the preceding step as input (StepAfter, StepAfterBoth).
+const EdgeKindData EdgeKind = "data"
+
+// EdgeKindOrder means the step only runs after the preceding step (ExecuteAfter, or the root step).
+const EdgeKindOrder EdgeKind = "order"
 
 // StepDefinitionMeta is the interface for a step definition
 type StepDefinitionMeta interface {
@@ -20,11 +38,45 @@ type StepDefinitionMeta interface {
 	// DependsOn return the list of step names that this step depends on
 	DependsOn() []string
 
+	// GetDescription return description of the step, set by WithDescription
+	GetDescription() string
+
+	// GetOwner return owner of the step, set by WithOwner
+	GetOwner() string
+
+	// GetTags return tags of the step, set by WithTags
+	GetTags() []string
+
+	// TelemetryAttributes return metadata of the step as attributes for logs, traces and metrics
+	TelemetryAttributes() map[string]string
+
 	// DotSpec used for generating graphviz graph
 	DotSpec() *graph.DotNodeSpec
 
 	// Instantiate a new step instance
 	createStepInstance(context.Context, JobInstanceMeta) StepInstanceMeta
+
+	// Instantiate a completed step instance, with output from an earlier run
+	createCompletedStepInstance(JobInstanceMeta, *completedStep) StepInstanceMeta
+
+	// Instantiate a step instance not executed, with state, output and error from a StateStore checkpoint
+	createCheckpointStepInstance(JobInstanceMeta, *StepCheckpoint, any) StepInstanceMeta
+
+	// decode step output saved in StateStore
+	decodeOutput([]byte, Codec) (any, error)
+
+	// InputSteps return the list of step names that output is taken as input by this step, a subset of DependsOn
+	InputSteps() []string
+
+	// DependencyKind return kind of the edge from a preceding step, empty if this step doesn't depend on it
+	DependencyKind(precedingStepName string) EdgeKind
+
+	// serializable description of the step, without edges
+	describe() *StepDescription
+
+	getErrorPolicy() StepErrorPolicy
+
+	getStepType() stepType
 }
 
 // StepDefinition defines a step and it's dependencies in a job definition.
@@ -32,7 +84,9 @@ type StepDefinition[T any] struct {
 	name             string
 	stepType         stepType
 	executionOptions *StepExecutionOptions
-	instanceCreator  func(context.Context, JobInstanceMeta) StepInstanceMeta
+	// preceding steps that output is taken as input, in parameter order.
+	inputSteps      []string
+	instanceCreator func(context.Context, JobInstanceMeta) StepInstanceMeta
 }
 
 func newStepDefinition[T any](stepName string, stepType stepType, optionDecorators ...ExecutionOptionPreparer) *StepDefinition[T] {
@@ -57,10 +111,149 @@ func (sd *StepDefinition[T]) DependsOn() []string {
 	return sd.executionOptions.DependOn
 }
 
+func (sd *StepDefinition[T]) GetDescription() string {
+	return sd.executionOptions.Description
+}
+
+func (sd *StepDefinition[T]) GetOwner() string {
+	return sd.executionOptions.Owner
+}
+
+func (sd *StepDefinition[T]) GetTags() []string {
+	return sd.executionOptions.Tags
+}
+
+// TelemetryAttributes returns step name, and description, owner, tags if they are set.
+func (sd *StepDefinition[T]) TelemetryAttributes() map[string]string {
+	attributes := map[string]string{"asyncjob.step.name": sd.GetName()}
+	if sd.executionOptions.Description != "" {
+		attributes["asyncjob.step.description"] = sd.executionOptions.Description
+	}
+	if sd.executionOptions.Owner != "" {
+		attributes["asyncjob.step.owner"] = sd.executionOptions.Owner
+	}
+	if len(sd.executionOptions.Tags) > 0 {
+		attributes["asyncjob.step.tags"] = strings.Join(sd.executionOptions.Tags, ",")
+	}
+
+	return attributes
+}
+
+func (sd *StepDefinition[T]) InputSteps() []string {
+	return sd.inputSteps
+}
+
+func (sd *StepDefinition[T]) DependencyKind(precedingStepName string) EdgeKind {
+	for _, inputStep := range sd.inputSteps {
+		if inputStep == precedingStepName {
+			return EdgeKindData
+		}
+	}
+	for _, dependOn := range sd.executionOptions.DependOn {
+		if dependOn == precedingStepName {
+			return EdgeKindOrder
+		}
+	}
+
+	return ""
+}
+
+func (sd *StepDefinition[T]) getErrorPolicy() StepErrorPolicy {
+	return sd.executionOptions.ErrorPolicy
+}
+
+func (sd *StepDefinition[T]) getStepType() stepType {
+	return sd.stepType
+}
+
+func (sd *StepDefinition[T]) describe() *StepDescription {
+	description := &StepDescription{
+		Name:              sd.GetName(),
+		Kind:              string(sd.stepType),
+		OutputType:        typeName[T](),
+		Description:       sd.executionOptions.Description,
+		Owner:             sd.executionOptions.Owner,
+		Tags:              sd.executionOptions.Tags,
+		ContextEnrichment: sd.executionOptions.ContextPolicy != nil,
+		RateLimiter:       sd.executionOptions.RateLimiter,
+	}
+	if sd.executionOptions.RetryPolicy != nil {
+		description.RetryPolicy = reflect.TypeOf(sd.executionOptions.RetryPolicy).String()
+	}
+	if sd.executionOptions.CachePolicy != nil {
+		description.CacheTTL = sd.executionOptions.CachePolicy.TTL.String()
+	}
+
+	return description
+}
+
 func (sd *StepDefinition[T]) createStepInstance(ctx context.Context, jobInstance JobInstanceMeta) StepInstanceMeta {
 	return sd.instanceCreator(ctx, jobInstance)
 }
 
+func (sd *StepDefinition[T]) createCompletedStepInstance(jobInstance JobInstanceMeta, completed *completedStep) StepInstanceMeta {
+	// TODO: error is ignored here
+	precedingInstances, _, _ := getDependsOnStepInstances(sd, jobInstance)
+
+	// output can be nil for interface type T
+	output, _ := completed.output.(T)
+
+	stepInstance := newStepInstance(sd, jobInstance)
+	// same as how root step is seeded with job input
+	stepInstance.task = asynctask.NewCompletedTask(output)
+	stepInstance.executionData = completed.executionData
+	stepInstance.setState(StepStateCompleted, nil)
+	jobInstance.addStepInstance(stepInstance, precedingInstances...)
+	return stepInstance
+}
+
+// createCheckpointStepInstance creates a step instance of a read-only job instance, checkpoint is nil if the step never saved a state.
+//
+//	the job is finished in the checkpoint, so a step not completed or failed is never executed, it is left pending, to be skipped.
+func (sd *StepDefinition[T]) createCheckpointStepInstance(jobInstance JobInstanceMeta, checkpoint *StepCheckpoint, output any) StepInstanceMeta {
+	precedingInstances, _, _ := getDependsOnStepInstances(sd, jobInstance)
+
+	stepInstance := newStepInstance(sd, jobInstance)
+	if checkpoint != nil {
+		stepInstance.executionData = &StepExecutionData{StartTime: checkpoint.StartTime, Duration: checkpoint.Duration}
+	}
+	switch {
+	case checkpoint != nil && checkpoint.State == StepStateCompleted:
+		stepInstance.state = StepStateCompleted
+		// output can be nil for interface type T
+		typedOutput, _ := output.(T)
+		stepInstance.task = asynctask.NewCompletedTask(typedOutput)
+	case checkpoint != nil && checkpoint.State == StepStateFailed:
+		stepInstance.state = StepStateFailed
+		stepErr := newStepError(ErrStepFailed, stepInstance, errors.New(checkpoint.Error))
+		stepInstance.task = asynctask.Start(context.Background(), func(context.Context) (T, error) { return *new(T), stepErr })
+	default:
+		// never executed, it fails with error of the failed preceding step, same as a step skipped in a live run.
+		var precedingTasks []asynctask.Waitable
+		for _, preceding := range precedingInstances {
+			precedingTasks = append(precedingTasks, preceding.Waitable())
+		}
+		stepInstance.task = asynctask.Start(context.Background(), func(ctx context.Context) (T, error) {
+			if err := asynctask.WaitAll(ctx, &asynctask.WaitAllOptions{}, precedingTasks...); err != nil {
+				return *new(T), err
+			}
+			return *new(T), fmt.Errorf("step %q is not executed in the checkpoint", sd.GetName())
+		})
+	}
+
+	jobInstance.addStepInstance(stepInstance, precedingInstances...)
+	return stepInstance
+}
+
+func (sd *StepDefinition[T]) decodeOutput(data []byte, codec Codec) (any, error) {
+	var output T
+	if err := codec.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
 func (sd *StepDefinition[T]) DotSpec() *graph.DotNodeSpec {
 	return &graph.DotNodeSpec{
 		Name:        sd.GetName(),
@@ -68,17 +261,42 @@ func (sd *StepDefinition[T]) DotSpec() *graph.DotNodeSpec {
 		Shape:       "box",
 		Style:       "filled",
 		FillColor:   "gray",
-		Tooltip:     "",
+		Tooltip:     strings.TrimPrefix(stepMetadataTooltip(sd), "\n"),
 	}
 }
 
+// stepMetadataTooltip returns a line for each of description, owner and tags that is set.
+func stepMetadataTooltip(sd StepDefinitionMeta) string {
+	tooltip := ""
+	if description := sd.GetDescription(); description != "" {
+		tooltip += "\nDescription: " + description
+	}
+	if owner := sd.GetOwner(); owner != "" {
+		tooltip += "\nOwner: " + owner
+	}
+	if tags := sd.GetTags(); len(tags) > 0 {
+		tooltip += "\nTags: " + strings.Join(tags, ", ")
+	}
+
+	return tooltip
+}
+
 func connectStepDefinition(stepFrom, stepTo StepDefinitionMeta) *graph.DotEdgeSpec {
 	edgeSpec := &graph.DotEdgeSpec{
 		FromNodeName: stepFrom.GetName(),
 		ToNodeName:   stepTo.GetName(),
 		Color:        "black",
-		Style:        "bold",
+		Style:        edgeStyle(stepFrom, stepTo),
 	}
 
 	return edgeSpec
 }
+
+// edgeStyle draws order-only edges dashed, data edges bold.
+func edgeStyle(stepFrom, stepTo StepDefinitionMeta) string {
+	if stepTo.DependencyKind(stepFrom.GetName()) == EdgeKindOrder {
+		return "dashed"
+	}
+
+	return "bold"
+}
@@ -0,0 +1,160 @@
+package asyncjob
+
+import (
+	"sync"
+	"time"
+)
+
+// StepEvent is a state transition of a step instance, or a progress update of a running step.
+type StepEvent struct {
+	JobId    string
+	StepName string
+	From     StepState
+	To       StepState
+	Time     time.Time
+	// Error of the step, when transition to failed.
+	Error string
+	// Progress reported by the step, From and To are both current state of the step, nil for a state transition.
+	Progress *StepProgress
+}
+
+// EventOverflowPolicy decides what happens when buffer of Events() channel is full.
+type EventOverflowPolicy string
+
+// EventOverflowDropNewest drops the event being sent, steps are never blocked, this is the default.
+const EventOverflowDropNewest EventOverflowPolicy = "drop-newest"
+
+// EventOverflowDropOldest drops the oldest event in buffer, to make room for the event being sent.
+const EventOverflowDropOldest EventOverflowPolicy = "drop-oldest"
+
+// EventOverflowBlock blocks the step until the event is received, use it only if Events() is always consumed.
+//
+//	a blocked step gives up sending once the job is cancelled, the event is dropped then.
+const EventOverflowBlock EventOverflowPolicy = "block"
+
+const defaultEventBufferSize = 64
+
+// WithEventBuffer sets buffer size of Events() channel (default 64), and what to do when it is full (default EventOverflowDropNewest).
+func WithEventBuffer(size int, overflowPolicy EventOverflowPolicy) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.EventBufferSize = size
+		options.EventOverflowPolicy = overflowPolicy
+		return options
+	}
+}
+
+// stepEvents is the Events() channel of a job instance, sends are serialized to apply overflow policy,
+//
+//	except blocked sends of EventOverflowBlock, they don't hold the mutex while waiting for the receiver.
+type stepEvents struct {
+	mutex          sync.Mutex
+	ch             chan StepEvent
+	overflowPolicy EventOverflowPolicy
+	closed         bool
+
+	// blocked sends in flight, ch is closed after they returned.
+	blocked sync.WaitGroup
+	// closing is closed when the job finished, blocked sends give up.
+	closing chan struct{}
+	// cancelled is Done of the job context, blocked sends give up once the job is cancelled.
+	cancelled <-chan struct{}
+}
+
+func newStepEvents(options *JobExecutionOptions) *stepEvents {
+	size := options.EventBufferSize
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+
+	overflowPolicy := options.EventOverflowPolicy
+	if overflowPolicy == "" {
+		overflowPolicy = EventOverflowDropNewest
+	}
+
+	return &stepEvents{ch: make(chan StepEvent, size), overflowPolicy: overflowPolicy, closing: make(chan struct{})}
+}
+
+func (e *stepEvents) send(event StepEvent) {
+	e.mutex.Lock()
+	if e.closed {
+		e.mutex.Unlock()
+		return
+	}
+
+	if e.overflowPolicy == EventOverflowBlock {
+		e.blocked.Add(1)
+		e.mutex.Unlock()
+		defer e.blocked.Done()
+
+		select {
+		case e.ch <- event:
+		case <-e.closing:
+		case <-e.cancelled:
+		}
+		return
+	}
+
+	defer e.mutex.Unlock()
+	switch e.overflowPolicy {
+	case EventOverflowDropOldest:
+		for {
+			select {
+			case e.ch <- event:
+				return
+			default:
+			}
+			select {
+			case <-e.ch:
+			default:
+			}
+		}
+	default:
+		select {
+		case e.ch <- event:
+		default:
+		}
+	}
+}
+
+func (e *stepEvents) close() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.closed = true
+	close(e.closing)
+	e.blocked.Wait()
+	close(e.ch)
+}
+
+// Events returns a channel streaming state transitions of steps, it is closed once the job finished.
+//
+//	root step is not included, see WithEventBuffer for buffering and overflow.
+func (ji *JobInstance[T]) Events() <-chan StepEvent {
+	return ji.events.ch
+}
+
+func (ji *JobInstance[T]) emitStepEvent(step StepInstanceMeta, from, to StepState, stepErr error) {
+	event := StepEvent{
+		JobId:    ji.GetJobInstanceId(),
+		StepName: step.GetName(),
+		From:     from,
+		To:       to,
+		Time:     ji.jobOptions.Clock.Now(),
+	}
+	if stepErr != nil {
+		event.Error = stepErr.Error()
+	}
+
+	ji.events.send(event)
+}
+
+func (ji *JobInstance[T]) emitStepProgress(step StepInstanceMeta, state StepState, progress StepProgress) {
+	ji.events.send(StepEvent{
+		JobId:    ji.GetJobInstanceId(),
+		StepName: step.GetName(),
+		From:     state,
+		To:       state,
+		Time:     progress.UpdateTime,
+		Progress: &progress,
+	})
+}
@@ -9,9 +9,44 @@ type StepExecutionData struct {
 	StartTime time.Time
 	Duration  time.Duration
 	Retried   *RetryReport
+	// Cached is true if step output is from StepCache, step func is not executed.
+	Cached bool
+	// Captured inputs and output, if WithCapture is used on the job.
+	Captured *StepCapture
+	// Compensation of the step, if it is compensated after the job failed.
+	Compensation *CompensationReport
+	// RateLimitWait is the time waited for the rate limiter of the step, included in Duration, see WithRateLimit.
+	RateLimitWait time.Duration
 }
 
-// RetryReport would record the retry count (could extend to include each retry duration, ...)
+// copy returns a copy of the execution data, retry report and capture are copied as they are updated while the step runs.
+func (d *StepExecutionData) copy() *StepExecutionData {
+	copied := *d
+	if d.Retried != nil {
+		retried := *d.Retried
+		retried.Attempts = append([]*AttemptReport(nil), d.Retried.Attempts...)
+		copied.Retried = &retried
+	}
+	if d.Captured != nil {
+		captured := *d.Captured
+		copied.Captured = &captured
+	}
+
+	return &copied
+}
+
+// RetryReport would record the retry count, and start time, duration of each attempt.
 type RetryReport struct {
-	Count int
+	Count    int
+	Attempts []*AttemptReport
+}
+
+// AttemptReport records one execution of the step func.
+type AttemptReport struct {
+	StartTime time.Time
+	Duration  time.Duration
+	// RateLimitWait is the time waited for the rate limiter at start of the attempt, included in Duration.
+	RateLimitWait time.Duration
+	// error message, empty if attempt succeeded.
+	Error string
 }
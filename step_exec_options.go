@@ -9,12 +9,35 @@ type StepExecutionOptions struct {
 	ErrorPolicy   StepErrorPolicy
 	RetryPolicy   RetryPolicy
 	ContextPolicy StepContextPolicy
+	CachePolicy   *StepCachePolicy
+	// Compensation undoes the completed step if the job fails, see WithCompensation.
+	Compensation StepCompensation
+	// RateLimiter is name of the rate limiter in RateLimiterRegistry of the job, see WithRateLimit.
+	RateLimiter string
 
 	// dependencies that are not input.
 	DependOn []string
+
+	// metadata of the step, not used in execution.
+	Description string
+	Owner       string
+	Tags        []string
+}
+
+// StepErrorPolicy decide how failure of preceding steps affects a step.
+//
+//	failure of a data dependency (StepAfter, StepAfterBoth) always fails the step, as there is no input to run with.
+type StepErrorPolicy struct {
+	// IgnoreOrderDependencyFailure runs the step after order-only dependencies (ExecuteAfter) finished, even if they failed.
+	IgnoreOrderDependencyFailure bool
 }
 
-type StepErrorPolicy struct{}
+// StepCachePolicy skip execution of a step, if output of same input is found in Cache.
+type StepCachePolicy struct {
+	Cache   StepCache
+	KeyFunc CacheKeyFunc
+	TTL     time.Duration
+}
 
 type RetryPolicy interface {
 	ShouldRetry(error) (bool, time.Duration)
@@ -43,9 +66,54 @@ func WithRetry(retryPolicy RetryPolicy) ExecutionOptionPreparer {
 	}
 }
 
+// Set how failure of preceding steps affects the step.
+func WithErrorPolicy(errorPolicy StepErrorPolicy) ExecutionOptionPreparer {
+	return func(options *StepExecutionOptions) *StepExecutionOptions {
+		options.ErrorPolicy = errorPolicy
+		return options
+	}
+}
+
 func WithContextEnrichment(contextPolicy StepContextPolicy) ExecutionOptionPreparer {
 	return func(options *StepExecutionOptions) *StepExecutionOptions {
 		options.ContextPolicy = contextPolicy
 		return options
 	}
 }
+
+// Cache step output in cache, keyed by keyFunc (HashCacheKey if nil) on step inputs, expires after ttl.
+//
+//	a cache hit skip running the step func, the step is marked completed with Cached flag in StepExecutionData.
+func WithCache(cache StepCache, keyFunc CacheKeyFunc, ttl time.Duration) ExecutionOptionPreparer {
+	return func(options *StepExecutionOptions) *StepExecutionOptions {
+		if keyFunc == nil {
+			keyFunc = HashCacheKey
+		}
+		options.CachePolicy = &StepCachePolicy{Cache: cache, KeyFunc: keyFunc, TTL: ttl}
+		return options
+	}
+}
+
+// Describe what the step does, shown in graph tooltip, snapshot and telemetry attributes.
+func WithDescription(description string) ExecutionOptionPreparer {
+	return func(options *StepExecutionOptions) *StepExecutionOptions {
+		options.Description = description
+		return options
+	}
+}
+
+// Team or person owning the step, failures of the step can be routed to the owner.
+func WithOwner(owner string) ExecutionOptionPreparer {
+	return func(options *StepExecutionOptions) *StepExecutionOptions {
+		options.Owner = owner
+		return options
+	}
+}
+
+// Tag the step, tags are appended if used more than once.
+func WithTags(tags ...string) ExecutionOptionPreparer {
+	return func(options *StepExecutionOptions) *StepExecutionOptions {
+		options.Tags = append(options.Tags, tags...)
+		return options
+	}
+}
@@ -0,0 +1,106 @@
+package asyncjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/Azure/go-asynctask"
+)
+
+// StepOutcome is how a preceding step of a finally step ended.
+type StepOutcome struct {
+	StepName string
+	// State of the step when the finally step started, pending if it never started.
+	State StepState
+	// Output of the step, nil if it is not completed.
+	Output any
+	// Error of the step, nil if it is completed.
+	Error *JobError
+}
+
+// FinallyFunc is the step func of a finally step, outcomes are keyed by name of the preceding steps.
+type FinallyFunc[ST any] func(ctx context.Context, outcomes map[string]*StepOutcome) (ST, error)
+
+// AddFinallyStep adds a cleanup step (close connection, release lock, send notification), that runs once precedents finished,
+//
+//	no matter they completed, failed or cancelled. it runs after the root step if precedents is empty.
+//	the step context is detached from cancellation of the job, the step always runs before Wait returns.
+func AddFinallyStep[JT, ST any](j *JobDefinition[JT], stepName string, precedents []StepDefinitionMeta, stepFuncCreator func(input JT) FinallyFunc[ST], optionDecorators ...ExecutionOptionPreparer) (*StepDefinition[ST], error) {
+	if err := addStepPreCheck(j, stepName); err != nil {
+		return nil, err
+	}
+
+	for _, precedent := range precedents {
+		optionDecorators = append(optionDecorators, ExecuteAfter(precedent))
+	}
+	stepD := newStepDefinition[ST](stepName, stepTypeFinally, optionDecorators...)
+	// precedents are order dependencies, so they are waited without failing this step.
+	stepD.executionOptions.ErrorPolicy.IgnoreOrderDependencyFailure = true
+	precedingDefSteps, err := getDependsOnSteps(j, stepD.DependsOn())
+	if err != nil {
+		return nil, err
+	}
+
+	// if a step have no preceding tasks, link it to our rootJob as preceding task, so it won't start yet.
+	if len(precedingDefSteps) == 0 {
+		precedingDefSteps = append(precedingDefSteps, j.getRootStep())
+		stepD.executionOptions.DependOn = append(stepD.executionOptions.DependOn, j.getRootStep().GetName())
+	}
+
+	stepD.instanceCreator = func(ctx context.Context, ji JobInstanceMeta) StepInstanceMeta {
+		// TODO: error is ignored here
+		precedingInstances, precedingTasks, _ := getDependsOnStepInstances(stepD, ji)
+
+		jiStrongTyped := ji.(*JobInstance[JT])
+		stepFunc := stepFuncCreator(jiStrongTyped.input)
+		stepFuncWithPanicHandling := func(ctx context.Context) (result ST, err error) {
+			// handle panic from user code
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic cought: %v, StackTrace: %s", r, debug.Stack())
+				}
+			}()
+
+			result, err = stepFunc(ctx, stepOutcomes(ji, precedingInstances))
+			return result, err
+		}
+
+		stepInstance := newStepInstance(stepD, ji)
+		stepInstance.task = asynctask.Start(detachedContext{ctx}, instrumentedAddStep(stepInstance, precedingTasks, stepFuncWithPanicHandling))
+		ji.addStepInstance(stepInstance, precedingInstances...)
+		return stepInstance
+	}
+
+	if err := j.addStep(stepD, precedingDefSteps...); err != nil {
+		return nil, err
+	}
+	return stepD, nil
+}
+
+// stepOutcomes collects outcomes of finished steps, root step is excluded.
+func stepOutcomes(ji JobInstanceMeta, steps []StepInstanceMeta) map[string]*StepOutcome {
+	outcomes := map[string]*StepOutcome{}
+	for _, step := range steps {
+		if step.GetName() == ji.GetJobDefinition().GetName() {
+			continue
+		}
+
+		outcome := &StepOutcome{StepName: step.GetName(), State: step.GetState()}
+		if completed := step.getCompletedStep(); completed != nil {
+			outcome.Output = completed.output
+		} else if err := step.Waitable().Wait(context.Background()); err != nil {
+			jobErr := &JobError{}
+			if !errors.As(err, &jobErr) || jobErr.StepInstance != step {
+				// error from the job context or a preceding step, the step didn't get to run.
+				jobErr = newStepError(ErrPrecedentStepFailed, step, err)
+				jobErr.Message = err.Error()
+			}
+			outcome.Error = jobErr
+		}
+		outcomes[step.GetName()] = outcome
+	}
+
+	return outcomes
+}
@@ -0,0 +1,112 @@
+package asyncjob_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinallyStep(t *testing.T) {
+	t.Parallel()
+
+	jd := asyncjob.NewJobDefinition[time.Duration]("finallyJob")
+	conn, err := asyncjob.AddStep(jd, "Open", func(time.Duration) asynctask.AsyncFunc[string] {
+		return func(ctx context.Context) (string, error) { return "conn1", nil }
+	})
+	assert.NoError(t, err)
+	query, err := asyncjob.StepAfter(jd, "Query", conn, func(delay time.Duration) asynctask.ContinueFunc[string, int] {
+		return func(ctx context.Context, conn string) (int, error) {
+			select {
+			case <-time.After(delay):
+				return 0, errors.New("table not found")
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+	})
+	assert.NoError(t, err)
+	report, err := asyncjob.StepAfter(jd, "Report", query, func(time.Duration) asynctask.ContinueFunc[int, int] {
+		return func(ctx context.Context, rows int) (int, error) { return rows, nil }
+	})
+	assert.NoError(t, err)
+
+	outcomesCh := make(chan map[string]*asyncjob.StepOutcome, 1)
+	_, err = asyncjob.AddFinallyStep(jd, "Close", []asyncjob.StepDefinitionMeta{conn, query, report}, func(time.Duration) asyncjob.FinallyFunc[string] {
+		return func(ctx context.Context, outcomes map[string]*asyncjob.StepOutcome) (string, error) {
+			assert.NoError(t, ctx.Err())
+			outcomesCh <- outcomes
+			conn, _ := outcomes["Open"].Output.(string)
+			return conn, nil
+		}
+	})
+	assert.NoError(t, err)
+
+	jobInstance := jd.Start(context.Background(), time.Millisecond)
+	assert.Error(t, jobInstance.Wait(context.Background()))
+	outcomes := <-outcomesCh
+	assert.Len(t, outcomes, 3)
+	assert.Equal(t, asyncjob.StepStateCompleted, outcomes["Open"].State)
+	assert.Nil(t, outcomes["Open"].Error)
+	assert.Equal(t, asyncjob.StepStateFailed, outcomes["Query"].State)
+	assert.Equal(t, asyncjob.ErrStepFailed, outcomes["Query"].Error.Code)
+	assert.Equal(t, "table not found", outcomes["Query"].Error.RootCause().(*asyncjob.JobError).StepError.Error())
+	assert.Equal(t, asyncjob.ErrPrecedentStepFailed, outcomes["Report"].Error.Code)
+	assert.Equal(t, outcomes["Query"].Error.RootCause(), outcomes["Report"].Error.RootCause())
+
+	closeStep, _ := jobInstance.GetStepInstance("Close")
+	assert.Equal(t, asyncjob.StepStateCompleted, closeStep.GetState())
+	assert.Equal(t, asyncjob.JobStatePartiallySucceeded, jobInstance.GetState())
+
+	// finally step runs after the job is cancelled.
+	jobInstance = jd.Start(context.Background(), time.Hour)
+	jobInstance.Cancel()
+	assert.Error(t, jobInstance.Wait(context.Background()))
+	outcomes = <-outcomesCh
+	assert.NotNil(t, outcomes["Query"].Error)
+	closeStep, _ = jobInstance.GetStepInstance("Close")
+	assert.Equal(t, asyncjob.StepStateCompleted, closeStep.GetState())
+}
+
+func TestFinallyStepRetryFailed(t *testing.T) {
+	t.Parallel()
+
+	var closed int32
+	jd := asyncjob.NewJobDefinition[string]("finallyRetryJob")
+	open, err := asyncjob.AddStepWithStaticFunc(jd, "Open", func(ctx context.Context) (string, error) { return "conn1", nil })
+	assert.NoError(t, err)
+	attempts := 0
+	_, err = asyncjob.AddStepWithStaticFunc(jd, "Query", func(ctx context.Context) (int, error) {
+		if attempts++; attempts == 1 {
+			return 0, errors.New("table not found")
+		}
+		return 1, nil
+	})
+	assert.NoError(t, err)
+	_, err = asyncjob.AddFinallyStep(jd, "Close", []asyncjob.StepDefinitionMeta{open}, func(string) asyncjob.FinallyFunc[string] {
+		return func(ctx context.Context, outcomes map[string]*asyncjob.StepOutcome) (string, error) {
+			atomic.AddInt32(&closed, 1)
+			return "closed", nil
+		}
+	})
+	assert.NoError(t, err)
+
+	jobInstance := jd.Start(context.Background(), "input")
+	assert.Error(t, jobInstance.Wait(context.Background()))
+	closeStep, _ := jobInstance.GetStepInstance("Close")
+	assert.Equal(t, asyncjob.StepStateCompleted, closeStep.GetState())
+
+	// Close only depends on Open, which is reused, Close still runs again.
+	retried, err := jobInstance.RetryFailed(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, retried.Wait(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&closed))
+	openStep, _ := jobInstance.GetStepInstance("Open")
+	retriedOpen, _ := retried.GetStepInstance("Open")
+	assert.Equal(t, openStep.ExecutionData().StartTime, retriedOpen.ExecutionData().StartTime)
+}
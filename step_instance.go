@@ -3,6 +3,8 @@ package asyncjob
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/go-asyncjob/graph"
@@ -16,6 +18,15 @@ const StepStateRunning StepState = "running"
 const StepStateFailed StepState = "failed"
 const StepStateCompleted StepState = "completed"
 
+// StepStateRetrying is a step waiting to retry, after an attempt failed.
+const StepStateRetrying StepState = "retrying"
+
+// StepStateWaiting is a step added by WaitForSignal, waiting for JobInstance.Signal.
+const StepStateWaiting StepState = "waiting"
+
+// StepStateSkipped is a step never started, as preceding steps failed or the job is cancelled.
+const StepStateSkipped StepState = "skipped"
+
 // StepInstanceMeta is the interface for a step instance
 type StepInstanceMeta interface {
 	GetName() string
@@ -24,8 +35,16 @@ type StepInstanceMeta interface {
 	GetJobInstance() JobInstanceMeta
 	GetStepDefinition() StepDefinitionMeta
 	Waitable() asynctask.Waitable
+	Progress() *StepProgress
 
 	DotSpec() *graph.DotNodeSpec
+
+	// not exposing for now
+	getCompletedStep() *completedStep
+	getError() error
+	skipIfPending()
+	signal(value any) error
+	compensate(context.Context) *CompensationReport
 }
 
 // StepInstance is the instance of a step, within a job instance.
@@ -33,9 +52,17 @@ type StepInstance[T any] struct {
 	Definition  *StepDefinition[T]
 	JobInstance JobInstanceMeta
 
-	task          *asynctask.Task[T]
+	task *asynctask.Task[T]
+
+	// mutex guards state, executionData, progress and signalsClosed, they are written by the step goroutine and read by anyone.
+	mutex         sync.RWMutex
 	state         StepState
 	executionData *StepExecutionData
+	progress      *StepProgress
+
+	// only for steps added by WaitForSignal.
+	signals       chan T
+	signalsClosed bool
 }
 
 func newStepInstance[T any](stepDefinition *StepDefinition[T], jobInstance JobInstanceMeta) *StepInstance[T] {
@@ -64,9 +91,94 @@ func (si *StepInstance[T]) GetName() string {
 }
 
 func (si *StepInstance[T]) GetState() StepState {
+	si.mutex.RLock()
+	defer si.mutex.RUnlock()
+
 	return si.state
 }
 
+// setState changes state of the step, and emits a StepEvent, stepErr is only set when the step failed.
+func (si *StepInstance[T]) setState(state StepState, stepErr error) {
+	si.mutex.Lock()
+	from := si.state
+	si.state = state
+	si.mutex.Unlock()
+
+	si.JobInstance.emitStepEvent(si, from, state, stepErr)
+}
+
+func (si *StepInstance[T]) skipIfPending() {
+	si.mutex.Lock()
+	from := si.state
+	if from != StepStatePending {
+		si.mutex.Unlock()
+		return
+	}
+	si.state = StepStateSkipped
+	si.mutex.Unlock()
+
+	si.JobInstance.emitStepEvent(si, from, StepStateSkipped, nil)
+}
+
+// updateExecutionData applies update to execution data of the step under the lock.
+func (si *StepInstance[T]) updateExecutionData(update func(*StepExecutionData)) {
+	si.mutex.Lock()
+	defer si.mutex.Unlock()
+
+	update(si.executionData)
+}
+
+// getCompletedStep returns the output of a completed step, so it can be reused by another attempt.
+func (si *StepInstance[T]) getCompletedStep() *completedStep {
+	if si.GetState() != StepStateCompleted {
+		return nil
+	}
+
+	// task is completed, Result returns immediately.
+	output, err := si.task.Result(context.Background())
+	if err != nil {
+		return nil
+	}
+
+	return &completedStep{output: output, executionData: si.ExecutionData()}
+}
+
+// getError returns error of a failed step, nil otherwise.
+func (si *StepInstance[T]) getError() error {
+	if si.GetState() != StepStateFailed {
+		return nil
+	}
+
+	// task is completed, Result returns immediately.
+	_, err := si.task.Result(context.Background())
+	return err
+}
+
+// cacheKey computes key of the step in StepCache, scoped by job and step name.
+func (si *StepInstance[T]) cacheKey(inputs []any) (string, error) {
+	key, err := si.Definition.executionOptions.CachePolicy.KeyFunc(si.JobInstance.getInput(), inputs)
+	if err != nil {
+		return "", err
+	}
+
+	return si.JobInstance.GetJobDefinition().GetName() + "/" + si.GetName() + "/" + key, nil
+}
+
+// getCachedOutput returns output of the step from StepCache, false if cacheKey is empty, not found or have different type.
+func (si *StepInstance[T]) getCachedOutput(cacheKey string) (T, bool) {
+	if cacheKey == "" {
+		return *new(T), false
+	}
+
+	cached, ok := si.Definition.executionOptions.CachePolicy.Cache.Get(cacheKey)
+	if !ok {
+		return *new(T), false
+	}
+
+	output, ok := cached.(T)
+	return output, ok
+}
+
 func (si *StepInstance[T]) EnrichContext(ctx context.Context) (result context.Context) {
 	result = ctx
 	if si.Definition.executionOptions.ContextPolicy != nil {
@@ -82,8 +194,45 @@ func (si *StepInstance[T]) EnrichContext(ctx context.Context) (result context.Co
 	return result
 }
 
+// ExecutionData returns a copy of execution data of the step, it is safe to read while the step is running.
 func (si *StepInstance[T]) ExecutionData() *StepExecutionData {
-	return si.executionData
+	si.mutex.RLock()
+	defer si.mutex.RUnlock()
+
+	return si.executionData.copy()
+}
+
+// saveCheckpoint records current state of the step in StateStore if configured,
+//
+//	output is only encoded when step is completed, stepErr is only recorded when step is failed.
+func (si *StepInstance[T]) saveCheckpoint(ctx context.Context, output T, stepErr error) error {
+	jobOptions := si.JobInstance.getJobOptions()
+	if jobOptions.StateStore == nil {
+		return nil
+	}
+
+	state := si.GetState()
+	executionData := si.ExecutionData()
+	checkpoint := &StepCheckpoint{
+		StepName:  si.GetName(),
+		State:     state,
+		StartTime: executionData.StartTime,
+		Duration:  executionData.Duration,
+	}
+
+	if state == StepStateCompleted {
+		encoded, err := jobOptions.Codec.Marshal(output)
+		if err != nil {
+			return err
+		}
+		checkpoint.Output = encoded
+	}
+
+	if stepErr != nil {
+		checkpoint.Error = stepErr.Error()
+	}
+
+	return jobOptions.StateStore.SaveStep(ctx, si.JobInstance.GetJobInstanceId(), checkpoint)
 }
 
 func (si *StepInstance[T]) DotSpec() *graph.DotNodeSpec {
@@ -92,8 +241,11 @@ func (si *StepInstance[T]) DotSpec() *graph.DotNodeSpec {
 		shape = "triangle"
 	}
 
+	state := si.GetState()
+	executionData := si.ExecutionData()
+
 	color := "gray"
-	switch si.state {
+	switch state {
 	case StepStatePending:
 		color = "gray"
 	case StepStateRunning:
@@ -102,18 +254,33 @@ func (si *StepInstance[T]) DotSpec() *graph.DotNodeSpec {
 		color = "green"
 	case StepStateFailed:
 		color = "red"
+	case StepStateRetrying:
+		color = "orange"
+	case StepStateSkipped:
+		color = "lightgray"
+	case StepStateWaiting:
+		color = "lightblue"
 	}
 
+	style := "filled"
 	tooltip := ""
-	if si.state != StepStatePending && si.executionData != nil {
-		tooltip = fmt.Sprintf("State: %s\\nStartAt: %s\\nDuration: %s", si.state, si.executionData.StartTime.Format(time.RFC3339Nano), si.executionData.Duration)
+	if state != StepStatePending && state != StepStateSkipped {
+		tooltip = fmt.Sprintf("State: %s\nStartAt: %s\nDuration: %s", state, executionData.StartTime.Format(time.RFC3339Nano), executionData.Duration)
+		if executionData.Cached {
+			style = "filled,dashed"
+			tooltip += "\nCached: true"
+		}
+		tooltip += progressTooltip(si.Progress())
+		tooltip += compensationTooltip(executionData.Compensation)
+		tooltip += rateLimitTooltip(executionData)
 	}
+	tooltip = strings.TrimPrefix(tooltip+stepMetadataTooltip(si.Definition), "\n")
 
 	return &graph.DotNodeSpec{
 		Name:        si.GetName(),
 		DisplayName: si.GetName(),
 		Shape:       shape,
-		Style:       "filled",
+		Style:       style,
 		FillColor:   color,
 		Tooltip:     tooltip,
 	}
@@ -124,11 +291,11 @@ func connectStepInstance(stepFrom, stepTo StepInstanceMeta) *graph.DotEdgeSpec {
 		FromNodeName: stepFrom.GetName(),
 		ToNodeName:   stepTo.GetName(),
 		Color:        "black",
-		Style:        "bold",
+		Style:        edgeStyle(stepFrom.GetStepDefinition(), stepTo.GetStepDefinition()),
 	}
 
 	// update edge color, tooltip if NodeTo is started already.
-	if stepTo.GetState() != StepStatePending {
+	if stepTo.GetState() != StepStatePending && stepTo.GetState() != StepStateSkipped {
 		executionData := stepTo.ExecutionData()
 		edgeSpec.Tooltip = fmt.Sprintf("Time: %s", executionData.StartTime.Format(time.RFC3339Nano))
 	}
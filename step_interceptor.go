@@ -0,0 +1,43 @@
+package asyncjob
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// StepInterceptor wraps each execution (attempt) of step func in a job instance, used by testing harness or instrumentation.
+//
+//	next runs the step func, interceptor can skip it, replace its output or error, or delay it.
+//	output returned must be assignable to output type of the step, nil is zero value.
+type StepInterceptor func(ctx context.Context, step StepInstanceMeta, next func(context.Context) (any, error)) (any, error)
+
+// WithStepInterceptor wraps step funcs of the job instance with interceptor.
+func WithStepInterceptor(interceptor StepInterceptor) JobOptionPreparer {
+	return func(options *JobExecutionOptions) *JobExecutionOptions {
+		options.StepInterceptor = interceptor
+		return options
+	}
+}
+
+// interceptStepFunc returns stepFunc wrapped by interceptor, panic from interceptor is handled same as panic from step func.
+func interceptStepFunc[T any](stepInstance *StepInstance[T], interceptor StepInterceptor, stepFunc func(ctx context.Context) (T, error)) func(ctx context.Context) (T, error) {
+	return func(ctx context.Context) (result T, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic cought: %v, StackTrace: %s", r, debug.Stack())
+			}
+		}()
+
+		output, err := interceptor(ctx, stepInstance, func(ctx context.Context) (any, error) { return stepFunc(ctx) })
+		if err != nil || output == nil {
+			return *new(T), err
+		}
+
+		result, ok := output.(T)
+		if !ok {
+			return *new(T), ErrInterceptorOutputTypeMismatch.WithMessage(fmt.Sprintf(MsgInterceptorOutputTypeMismatch, output, stepInstance.GetName(), typeName[T]()))
+		}
+		return result, nil
+	}
+}
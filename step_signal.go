@@ -0,0 +1,141 @@
+package asyncjob
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/go-asynctask"
+)
+
+// WaitForSignal adds a step that waits for an external event, it completes with the value from JobInstance.Signal(stepName, value).
+//
+//	the step stays in StepStateWaiting until signaled, it fails with ErrSignalTimeout if not signaled within timeout (0 to wait until job is cancelled).
+//	downstream steps take the signal value with StepAfter or StepAfterBoth, as any other step.
+//	don't use it with WithSequentialExecution, Start would block until the step is signaled.
+func WaitForSignal[JT, ST any](j *JobDefinition[JT], stepName string, timeout time.Duration, optionDecorators ...ExecutionOptionPreparer) (*StepDefinition[ST], error) {
+	if err := addStepPreCheck(j, stepName); err != nil {
+		return nil, err
+	}
+
+	stepD := newStepDefinition[ST](stepName, stepTypeSignal, optionDecorators...)
+	precedingDefSteps, err := getDependsOnSteps(j, stepD.DependsOn())
+	if err != nil {
+		return nil, err
+	}
+
+	// if a step have no preceding tasks, link it to our rootJob as preceding task, so it won't start yet.
+	if len(precedingDefSteps) == 0 {
+		precedingDefSteps = append(precedingDefSteps, j.getRootStep())
+		stepD.executionOptions.DependOn = append(stepD.executionOptions.DependOn, j.getRootStep().GetName())
+	}
+
+	stepD.instanceCreator = func(ctx context.Context, ji JobInstanceMeta) StepInstanceMeta {
+		// TODO: error is ignored here
+		precedingInstances, precedingTasks, _ := getDependsOnStepInstances(stepD, ji)
+
+		stepInstance := newStepInstance(stepD, ji)
+		// buffered, so the step can be signaled before it starts waiting.
+		stepInstance.signals = make(chan ST, 1)
+		stepInstance.task = asynctask.Start(ctx, instrumentedAddStep(stepInstance, precedingTasks, func(ctx context.Context) (ST, error) {
+			return stepInstance.waitForSignal(ctx, timeout)
+		}))
+		ji.addStepInstance(stepInstance, precedingInstances...)
+		return stepInstance
+	}
+
+	if err := j.addStep(stepD, precedingDefSteps...); err != nil {
+		return nil, err
+	}
+	return stepD, nil
+}
+
+// waitForSignal blocks until the step is signaled, timeout fires or ctx is done, it is called for each attempt if the step is retried.
+func (si *StepInstance[T]) waitForSignal(ctx context.Context, timeout time.Duration) (T, error) {
+	// signals are closed by a failed earlier attempt, the step accepts a signal again.
+	si.mutex.Lock()
+	si.signalsClosed = false
+	si.mutex.Unlock()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timeoutC = si.JobInstance.getJobOptions().Clock.After(timeout)
+	}
+
+	si.setState(StepStateWaiting, nil)
+	var err error
+	select {
+	case value := <-si.signals:
+		si.setState(StepStateRunning, nil)
+		return value, nil
+	case <-timeoutC:
+		err = ErrSignalTimeout.WithMessage(fmt.Sprintf(MsgSignalTimeout, si.GetName(), timeout))
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	// a signal accepted just before signals are closed still completes the step, so it is never lost.
+	if value, ok := si.closeSignals(); ok {
+		si.setState(StepStateRunning, nil)
+		return value, nil
+	}
+	return *new(T), err
+}
+
+// closeSignals stops accepting signals, and returns the value if the step is signaled already.
+func (si *StepInstance[T]) closeSignals() (T, bool) {
+	si.mutex.Lock()
+	defer si.mutex.Unlock()
+
+	si.signalsClosed = true
+	select {
+	case value := <-si.signals:
+		return value, true
+	default:
+		return *new(T), false
+	}
+}
+
+// signal delivers value to a step added by WaitForSignal, value must be of the output type of the step.
+func (si *StepInstance[T]) signal(value any) error {
+	if si.signals == nil {
+		return ErrStepNotSignalable.WithMessage(fmt.Sprintf(MsgStepNotSignalable, si.GetName()))
+	}
+
+	typedValue, ok := value.(T)
+	if !ok {
+		return ErrSignalTypeMismatch.WithMessage(fmt.Sprintf(MsgSignalTypeMismatch, si.GetName(), typeName[T](), value))
+	}
+
+	si.mutex.Lock()
+	defer si.mutex.Unlock()
+
+	if si.signalsClosed && si.state == StepStateRetrying {
+		return ErrSignalNotAccepted.WithMessage(fmt.Sprintf(MsgSignalNotAccepted, si.GetName(), "retrying"))
+	}
+	if si.signalsClosed || si.task.State() != asynctask.StateRunning {
+		return ErrSignalNotAccepted.WithMessage(fmt.Sprintf(MsgSignalNotAccepted, si.GetName(), "finished"))
+	}
+
+	select {
+	case si.signals <- typedValue:
+		return nil
+	default:
+		return ErrSignalNotAccepted.WithMessage(fmt.Sprintf(MsgSignalNotAccepted, si.GetName(), "already signaled"))
+	}
+}
+
+// Signal delivers value to the step added by WaitForSignal, so it completes with value.
+//
+//	a step can be signaled once, before or while it is waiting, ErrSignalNotAccepted is returned once it stopped waiting.
+//	a value accepted while waiting always completes the step, even if timeout fires at the same time.
+//	a value accepted before the step starts waiting is lost if the step never runs, as a preceding step failed or the job is cancelled.
+//	with WithRetry, each attempt waits for a signal again, a step waiting to retry after timeout returns ErrSignalNotAccepted.
+func (ji *JobInstance[T]) Signal(stepName string, value any) error {
+	step, ok := ji.GetStepInstance(stepName)
+	if !ok {
+		return ErrRefStepNotInJob.WithMessage(fmt.Sprintf(MsgRefStepNotInJob, stepName))
+	}
+
+	return step.signal(value)
+}
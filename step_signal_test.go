@@ -0,0 +1,127 @@
+package asyncjob_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-asyncjob"
+	"github.com/Azure/go-asyncjob/asyncjobtest"
+	"github.com/Azure/go-asynctask"
+	"github.com/stretchr/testify/assert"
+)
+
+func buildApprovalJob(t *testing.T, timeout time.Duration, optionDecorators ...asyncjob.ExecutionOptionPreparer) *asyncjob.JobDefinitionWithResult[string, string] {
+	jd := asyncjob.NewJobDefinition[string]("approvalJob")
+	approval, err := asyncjob.WaitForSignal[string, bool](jd, "Approval", timeout, optionDecorators...)
+	assert.NoError(t, err)
+	apply, err := asyncjob.StepAfter(jd, "Apply", approval, func(change string) asynctask.ContinueFunc[bool, string] {
+		return func(ctx context.Context, approved bool) (string, error) {
+			if !approved {
+				return "rejected " + change, nil
+			}
+			return "applied " + change, nil
+		}
+	})
+	assert.NoError(t, err)
+
+	jdWithResult, err := asyncjob.JobWithResult(jd, apply)
+	assert.NoError(t, err)
+	return jdWithResult
+}
+
+func TestWaitForSignal(t *testing.T) {
+	t.Parallel()
+
+	jd := buildApprovalJob(t, 0)
+	for _, step := range jd.Describe().Steps {
+		if step.Name == "Approval" {
+			assert.Equal(t, "signal", step.Kind)
+		}
+	}
+
+	jobInstance := jd.Start(context.Background(), "change1")
+	for event := range jobInstance.Events() {
+		if event.StepName == "Approval" && event.To == asyncjob.StepStateWaiting {
+			break
+		}
+	}
+	step, _ := jobInstance.GetStepInstance("Approval")
+	assert.Equal(t, asyncjob.StepStateWaiting, step.GetState())
+
+	assert.True(t, errors.Is(jobInstance.Signal("Approval", "yes"), asyncjob.ErrSignalTypeMismatch))
+	assert.True(t, errors.Is(jobInstance.Signal("Apply", true), asyncjob.ErrStepNotSignalable))
+	assert.True(t, errors.Is(jobInstance.Signal("NotExist", true), asyncjob.ErrRefStepNotInJob))
+	assert.NoError(t, jobInstance.Signal("Approval", true))
+
+	result, err := jobInstance.Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "applied change1", result)
+	assert.True(t, errors.Is(jobInstance.Signal("Approval", false), asyncjob.ErrSignalNotAccepted))
+
+	// signal before the step is waiting.
+	jobInstance = jd.Start(context.Background(), "change2")
+	assert.NoError(t, jobInstance.Signal("Approval", false))
+	result, err = jobInstance.Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "rejected change2", result)
+}
+
+func TestWaitForSignalTimeout(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	jd := buildApprovalJob(t, time.Hour)
+
+	jobInstance := jd.Start(context.Background(), "change1", asyncjob.WithClock(clock))
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+
+	_, err := jobInstance.Result(context.Background())
+	assert.True(t, errors.Is(err, asyncjob.ErrSignalTimeout))
+	assert.Error(t, jobInstance.Wait(context.Background()))
+
+	step, _ := jobInstance.GetStepInstance("Approval")
+	assert.Equal(t, asyncjob.StepStateFailed, step.GetState())
+	apply, _ := jobInstance.GetStepInstance("Apply")
+	assert.Equal(t, asyncjob.StepStateSkipped, apply.GetState())
+
+	assert.True(t, errors.Is(jobInstance.Signal("Approval", true), asyncjob.ErrSignalNotAccepted))
+
+	// signal accepted when timeout fires at the same time, still completes the step.
+	jobInstance = jd.Start(context.Background(), "change2", asyncjob.WithClock(clock))
+	clock.BlockUntil(1)
+	assert.NoError(t, jobInstance.Signal("Approval", true))
+	clock.Advance(time.Hour)
+	result, err := jobInstance.Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "applied change2", result)
+}
+
+func TestWaitForSignalRetry(t *testing.T) {
+	t.Parallel()
+
+	clock := asyncjobtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	jd := buildApprovalJob(t, time.Hour, asyncjob.WithRetry(newLinearRetryPolicy(time.Minute, 1)))
+
+	jobInstance := jd.Start(context.Background(), "change1", asyncjob.WithClock(clock))
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+
+	// first attempt timed out, no signal is accepted until the step waits again.
+	clock.BlockUntil(1)
+	step, _ := jobInstance.GetStepInstance("Approval")
+	assert.Equal(t, asyncjob.StepStateRetrying, step.GetState())
+	err := jobInstance.Signal("Approval", true)
+	assert.True(t, errors.Is(err, asyncjob.ErrSignalNotAccepted))
+	assert.ErrorContains(t, err, "retrying")
+
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	assert.NoError(t, jobInstance.Signal("Approval", true))
+	result, err := jobInstance.Result(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "applied change1", result)
+	assert.Equal(t, 1, step.ExecutionData().Retried.Count)
+}
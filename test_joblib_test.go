@@ -110,7 +110,7 @@ func BuildJob(retryPolicies map[string]asyncjob.RetryPolicy) (*asyncjob.JobDefin
 		return nil, fmt.Errorf("error adding step CheckAuth: %w", err)
 	}
 
-	table1ClientTsk, err := asyncjob.StepAfter(job, "GetTableClient1", connTsk, tableClient1StepFunc, asyncjob.WithContextEnrichment(EnrichContext))
+	table1ClientTsk, err := asyncjob.StepAfter(job, "GetTableClient1", connTsk, tableClient1StepFunc, asyncjob.WithContextEnrichment(EnrichContext), asyncjob.WithDescription("get client of table1"), asyncjob.WithOwner("storage-team"), asyncjob.WithTags("storage", "table1"))
 	if err != nil {
 		return nil, fmt.Errorf("error adding step GetTableClient1: %w", err)
 	}
@@ -165,7 +165,7 @@ type SqlSummaryJobParameters struct {
 	Query1         string
 	Table2         string
 	Query2         string
-	ErrorInjection map[string]func() error
+	ErrorInjection map[string]func() error `json:"-"`
 	PanicInjection map[string]bool
 }
 
@@ -248,6 +248,10 @@ func (sql *SqlSummaryJobLib) ExecuteQuery(ctx context.Context, tableClient *SqlT
 	// uncomment the mutex code, and run test with --race
 	sql.mutex.Lock()
 	defer sql.mutex.Unlock()
+	if sql.data == nil {
+		// decoded from checkpoint
+		sql.data = make(map[string]interface{})
+	}
 	sql.data["serverName"] = tableClient.ServerName
 	sql.data[tableClient.TableName] = *queryString
 
@@ -0,0 +1,292 @@
+package asyncjob
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+)
+
+const timelineWidth = 1000.0
+const timelineLabelWidth = 200.0
+const timelineRowHeight = 28.0
+const timelineBarHeight = 16.0
+
+// RenderTimeline renders the job instance as a standalone html page, with a svg gantt chart.
+//
+//	one row per step, a bar for each attempt, the critical path highlighted,
+//	time waiting for preceding steps, idle time after they finished, and time waiting for the rate limiter are visible as lighter bars.
+func (ji *JobInstance[T]) RenderTimeline() (string, error) {
+	buf := new(bytes.Buffer)
+	if err := timelineTemplate.Execute(buf, ji.timeline()); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+type timelineRef struct {
+	Title     string
+	Subtitle  string
+	Width     float64
+	Height    float64
+	BarHeight float64
+	Ticks     []*timelineTick
+	Rows      []*timelineRow
+	// captured values, if WithCapture is used.
+	CapturedInput string
+	Captures      []*timelineCapture
+}
+
+type timelineCapture struct {
+	Name   string
+	Inputs []string
+	Output string
+}
+
+type timelineTick struct {
+	X     float64
+	Label string
+}
+
+type timelineRow struct {
+	Name     string
+	State    StepState
+	TextY    float64
+	BarY     float64
+	Critical bool
+	// waiting for preceding steps to finish
+	Waiting *timelineBar
+	// preceding steps finished, but step is not started yet
+	Idle *timelineBar
+	// waiting for the rate limiter, at start of each attempt
+	Throttled []*timelineBar
+	Attempts  []*timelineBar
+	Note      string
+}
+
+type timelineBar struct {
+	X       float64
+	Width   float64
+	Failed  bool
+	Tooltip string
+}
+
+func (ji *JobInstance[T]) timeline() *timelineRef {
+	ji.mutex.RLock()
+	state := ji.state
+	executionData := ji.executionData.copy()
+	ji.mutex.RUnlock()
+
+	jobStart := executionData.StartTime
+	jobEnd := executionData.EndTime
+	if !state.IsTerminal() {
+		jobEnd = ji.jobOptions.Clock.Now()
+	}
+	total := jobEnd.Sub(jobStart)
+	if total <= 0 {
+		total = time.Nanosecond
+	}
+
+	xOf := func(t time.Time) float64 {
+		return timelineLabelWidth + float64(t.Sub(jobStart))/float64(total)*timelineWidth
+	}
+	widthOf := func(d time.Duration) float64 {
+		return float64(d) / float64(total) * timelineWidth
+	}
+
+	steps := make([]StepInstanceMeta, 0, len(ji.steps))
+	for _, step := range ji.steps {
+		if step != ji.rootStep {
+			steps = append(steps, step)
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		si, sj := steps[i].ExecutionData().StartTime, steps[j].ExecutionData().StartTime
+		if si.Equal(sj) {
+			return steps[i].GetName() < steps[j].GetName()
+		}
+		// not started steps (zero time) go last
+		return !si.IsZero() && (sj.IsZero() || si.Before(sj))
+	})
+
+	criticalSteps := map[string]bool{}
+	for _, stepName := range ji.criticalPath().Path {
+		criticalSteps[stepName] = true
+	}
+
+	ref := &timelineRef{
+		Title:     fmt.Sprintf("%s (%s)", ji.Definition.GetName(), ji.GetJobInstanceId()),
+		Subtitle:  fmt.Sprintf("Attempt: %d, State: %s, StartAt: %s, Duration: %s", ji.attempt, state, jobStart.Format(time.RFC3339Nano), executionData.Duration),
+		Width:     timelineLabelWidth + timelineWidth + 20,
+		Height:    timelineRowHeight*float64(len(steps)+1) + 20,
+		BarHeight: timelineBarHeight,
+
+		CapturedInput: executionData.CapturedInput,
+	}
+
+	for i := 0; i <= 10; i++ {
+		ref.Ticks = append(ref.Ticks, &timelineTick{
+			X:     timelineLabelWidth + timelineWidth*float64(i)/10,
+			Label: (total * time.Duration(i) / 10).String(),
+		})
+	}
+
+	for i, step := range steps {
+		executionData := step.ExecutionData()
+		row := &timelineRow{
+			Name:     step.GetName(),
+			State:    step.GetState(),
+			TextY:    timelineRowHeight*float64(i+1) + timelineBarHeight,
+			BarY:     timelineRowHeight*float64(i+1) + (timelineRowHeight-timelineBarHeight)/2,
+			Critical: criticalSteps[step.GetName()],
+		}
+		ref.Rows = append(ref.Rows, row)
+		if captured := executionData.Captured; captured != nil {
+			ref.Captures = append(ref.Captures, &timelineCapture{Name: step.GetName(), Inputs: captured.Inputs, Output: captured.Output})
+		}
+
+		if executionData.StartTime.IsZero() {
+			row.Note = "not started"
+			continue
+		}
+		if executionData.StartTime.Before(jobStart) {
+			row.Note = "reused from earlier run"
+			continue
+		}
+		if executionData.Cached {
+			row.Note = "cached"
+		}
+
+		readyAt := jobStart
+		for _, precedingName := range step.GetStepDefinition().DependsOn() {
+			preceding, ok := ji.steps[precedingName]
+			if !ok || preceding == ji.rootStep {
+				continue
+			}
+			precedingData := preceding.ExecutionData()
+			if end := precedingData.StartTime.Add(precedingData.Duration); end.After(readyAt) {
+				readyAt = end
+			}
+		}
+		if readyAt.After(executionData.StartTime) {
+			readyAt = executionData.StartTime
+		}
+
+		row.Waiting = &timelineBar{X: xOf(jobStart), Width: widthOf(readyAt.Sub(jobStart)), Tooltip: fmt.Sprintf("waiting for preceding steps: %s", readyAt.Sub(jobStart))}
+		row.Idle = &timelineBar{X: xOf(readyAt), Width: widthOf(executionData.StartTime.Sub(readyAt)), Tooltip: fmt.Sprintf("idle: %s", executionData.StartTime.Sub(readyAt))}
+
+		if executionData.Retried != nil && len(executionData.Retried.Attempts) > 0 {
+			for attemptIndex, attempt := range executionData.Retried.Attempts {
+				row.addThrottled(xOf(attempt.StartTime), widthOf(attempt.RateLimitWait), attempt.RateLimitWait)
+				running := attempt.Duration - attempt.RateLimitWait
+				row.Attempts = append(row.Attempts, &timelineBar{
+					X:       xOf(attempt.StartTime.Add(attempt.RateLimitWait)),
+					Width:   widthOf(running),
+					Failed:  attempt.Error != "",
+					Tooltip: fmt.Sprintf("attempt %d: %s %s", attemptIndex+1, running, attempt.Error),
+				})
+			}
+		} else {
+			row.addThrottled(xOf(executionData.StartTime), widthOf(executionData.RateLimitWait), executionData.RateLimitWait)
+			running := executionData.Duration - executionData.RateLimitWait
+			row.Attempts = append(row.Attempts, &timelineBar{
+				X:       xOf(executionData.StartTime.Add(executionData.RateLimitWait)),
+				Width:   widthOf(running),
+				Failed:  step.GetState() == StepStateFailed,
+				Tooltip: fmt.Sprintf("%s: %s", step.GetState(), running),
+			})
+		}
+	}
+
+	return ref
+}
+
+// addThrottled adds a segment for time waited on the rate limiter, if any.
+func (row *timelineRow) addThrottled(x, width float64, waited time.Duration) {
+	if waited <= 0 {
+		return
+	}
+
+	row.Throttled = append(row.Throttled, &timelineBar{X: x, Width: width, Tooltip: fmt.Sprintf("rate limited: %s", waited)})
+}
+
+var timelineTemplate = template.Must(template.New("timeline").Funcs(template.FuncMap{
+	"px": func(f float64) string { return fmt.Sprintf("%.2f", f) },
+}).Parse(timelineTemplateText))
+
+const timelineTemplateText = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+	body { font-family: sans-serif; font-size: 13px; }
+	.tick { stroke: #ddd; }
+	.waiting { fill: #eee; }
+	.idle { fill: #fbd38d; }
+	.throttled { fill: #90cdf4; }
+	.attempt { fill: #68d391; }
+	.attempt.failed { fill: #fc8181; }
+	.critical .attempt { stroke: #c53030; stroke-width: 2; }
+	.critical .label { font-weight: bold; fill: #c53030; }
+	.note { fill: #888; font-style: italic; }
+	table.captured { border-collapse: collapse; }
+	table.captured td, table.captured th { border: 1px solid #ddd; padding: 4px; text-align: left; vertical-align: top; }
+	table.captured pre { margin: 0; white-space: pre-wrap; word-break: break-all; }
+</style>
+</head>
+<body>
+<h3>{{.Title}}</h3>
+<p>{{.Subtitle}}</p>
+<p>
+	<svg width="12" height="12"><rect class="waiting" width="12" height="12"/></svg> waiting for preceding steps
+	<svg width="12" height="12"><rect class="idle" width="12" height="12"/></svg> idle
+	<svg width="12" height="12"><rect class="attempt" width="12" height="12"/></svg> attempt
+	<svg width="12" height="12"><rect class="attempt failed" width="12" height="12"/></svg> failed attempt
+	<b style="color: #c53030">bold red</b>: critical path
+</p>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{px .Width}}" height="{{px .Height}}">
+{{- range .Ticks}}
+	<line class="tick" x1="{{px .X}}" y1="0" x2="{{px .X}}" y2="{{px $.Height}}"/>
+	<text x="{{px .X}}" y="14" text-anchor="middle">{{.Label}}</text>
+{{- end}}
+{{- range .Rows}}
+	<g class="row{{if .Critical}} critical{{end}}">
+		<title>{{.Name}}: {{.State}}</title>
+		<text class="label" x="4" y="{{px .TextY}}">{{.Name}}</text>
+	{{- $row := .}}
+	{{- with .Waiting}}
+		<rect class="waiting" x="{{px .X}}" y="{{px $row.BarY}}" width="{{px .Width}}" height="{{px $.BarHeight}}"><title>{{.Tooltip}}</title></rect>
+	{{- end}}
+	{{- with .Idle}}
+		<rect class="idle" x="{{px .X}}" y="{{px $row.BarY}}" width="{{px .Width}}" height="{{px $.BarHeight}}"><title>{{.Tooltip}}</title></rect>
+	{{- end}}
+	{{- range .Throttled}}
+		<rect class="throttled" x="{{px .X}}" y="{{px $row.BarY}}" width="{{px .Width}}" height="{{px $.BarHeight}}"><title>{{.Tooltip}}</title></rect>
+	{{- end}}
+	{{- range .Attempts}}
+		<rect class="attempt{{if .Failed}} failed{{end}}" x="{{px .X}}" y="{{px $row.BarY}}" width="{{px .Width}}" height="{{px $.BarHeight}}"><title>{{.Tooltip}}</title></rect>
+	{{- end}}
+	{{- if .Note}}
+		<text class="note" x="{{px $.Width}}" y="{{px .TextY}}" text-anchor="end">{{.Note}}</text>
+	{{- end}}
+	</g>
+{{- end}}
+</svg>
+{{- if or .CapturedInput .Captures}}
+<h4>Captured values</h4>
+<table class="captured">
+	<tr><th>step</th><th>inputs</th><th>output</th></tr>
+{{- if .CapturedInput}}
+	<tr><td>job input</td><td></td><td><pre>{{.CapturedInput}}</pre></td></tr>
+{{- end}}
+{{- range .Captures}}
+	<tr><td>{{.Name}}</td><td>{{range .Inputs}}<pre>{{.}}</pre>{{end}}</td><td><pre>{{.Output}}</pre></td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`
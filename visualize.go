@@ -0,0 +1,60 @@
+package asyncjob
+
+import (
+	"fmt"
+
+	"github.com/Azure/go-asyncjob/graph"
+)
+
+type VisualizeFormat string
+
+// VisualizeFormatDot renders graphviz dot, this is the default format.
+const VisualizeFormatDot VisualizeFormat = "dot"
+
+// VisualizeFormatMermaid renders mermaid flowchart.
+const VisualizeFormatMermaid VisualizeFormat = "mermaid"
+
+type VisualizeOptions struct {
+	Format VisualizeFormat
+	// HighlightCriticalPath color edges on critical path, only apply to finished job instance.
+	HighlightCriticalPath bool
+}
+
+type VisualizeOptionPreparer func(*VisualizeOptions) *VisualizeOptions
+
+// WithVisualizeFormat choose the output format of Visualize.
+func WithVisualizeFormat(format VisualizeFormat) VisualizeOptionPreparer {
+	return func(options *VisualizeOptions) *VisualizeOptions {
+		options.Format = format
+		return options
+	}
+}
+
+// WithCriticalPathHighlight color edges on the critical path of a finished job instance.
+func WithCriticalPathHighlight() VisualizeOptionPreparer {
+	return func(options *VisualizeOptions) *VisualizeOptions {
+		options.HighlightCriticalPath = true
+		return options
+	}
+}
+
+func newVisualizeOptions(optionDecorators ...VisualizeOptionPreparer) *VisualizeOptions {
+	options := &VisualizeOptions{Format: VisualizeFormatDot}
+	for _, decorator := range optionDecorators {
+		options = decorator(options)
+	}
+
+	return options
+}
+
+// visualize renders the graph (of job definition or job instance) in the format from options.
+func visualize[NT graph.NodeConstrain](g *graph.Graph[NT], graphSpec *graph.DotGraphSpec, options *VisualizeOptions) (string, error) {
+	switch options.Format {
+	case VisualizeFormatDot:
+		return g.ToDotGraphWithSpec(graphSpec)
+	case VisualizeFormatMermaid:
+		return g.ToMermaidFlowchartWithSpec(graphSpec)
+	default:
+		return "", ErrUnsupportedVisualizeFormat.WithMessage(fmt.Sprintf(MsgUnsupportedVisualizeFormat, options.Format))
+	}
+}